@@ -0,0 +1,61 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cloudresty/emit"
+)
+
+// countingWriter discards entries, counting their bytes
+type countingWriter struct {
+	bytes int
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.bytes += len(p)
+	return len(p), nil
+}
+
+// benchmarkEncoding logs a typical request entry in a format, reporting
+// the encoded size alongside ns/op
+func benchmarkEncoding(b *testing.B, format string, log func()) {
+	out := &countingWriter{}
+	emit.SetFormat(format)
+	emit.SetOutput(out)
+	defer func() {
+		emit.SetFormat("json")
+		emit.SetOutputToDiscard()
+	}()
+
+	b.ReportAllocs()
+	for b.Loop() {
+		log()
+	}
+	b.ReportMetric(float64(out.bytes)/float64(b.N), "bytes/entry")
+}
+
+func logStructured() {
+	emit.Info.StructuredFields("HTTP request",
+		emit.ZString("method", "GET"),
+		emit.ZString("path", "/api/v1/orders"),
+		emit.ZInt("status", 200),
+		emit.ZInt64("bytes", 5123),
+		emit.ZFloat64("ratio", 0.25),
+		emit.ZBool("cached", false),
+		emit.ZDuration("duration", 1500*time.Microsecond))
+}
+
+func logFields() {
+	emit.Info.Field("HTTP request", emit.NewFields().
+		String("method", "GET").
+		String("path", "/api/v1/orders").
+		Int("status", 200).
+		Float64("ratio", 0.25).
+		Bool("cached", false))
+}
+
+func BenchmarkEncodingJSONStructured(b *testing.B) { benchmarkEncoding(b, "json", logStructured) }
+func BenchmarkEncodingCBORStructured(b *testing.B) { benchmarkEncoding(b, "cbor", logStructured) }
+func BenchmarkEncodingJSONFields(b *testing.B)     { benchmarkEncoding(b, "json", logFields) }
+func BenchmarkEncodingCBORFields(b *testing.B)     { benchmarkEncoding(b, "cbor", logFields) }
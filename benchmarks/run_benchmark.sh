@@ -27,9 +27,11 @@ go mod tidy
 echo "✅ Dependencies ready"
 echo
 
-# Build the benchmark application
+# Build the benchmark application outside the source tree
 echo "Building benchmark application..."
-go build .
+BUILD_DIR="$(mktemp -d)"
+trap 'rm -rf "$BUILD_DIR"' EXIT
+go build -o "$BUILD_DIR/benchmarks" .
 echo "✅ Build complete"
 echo
 
@@ -43,7 +45,7 @@ echo
 
 # Run the benchmark application
 echo "🚀 Starting comprehensive benchmark suite..."
-./benchmarks
+"$BUILD_DIR/benchmarks"
 
 echo
 echo "=================================================="
@@ -0,0 +1,92 @@
+package emit
+
+import (
+	"path/filepath"
+	"runtime"
+	"strconv"
+)
+
+// CallerFormat selects how caller file paths are written
+type CallerFormat int
+
+const (
+	CALLER_FULL    CallerFormat = iota // Full path: "/src/app/handlers/user.go:42" (default; text formats trim it)
+	CALLER_TRIMMED                     // Package directory and file: "handlers/user.go:42"
+	CALLER_SHORT                       // File name only: "user.go:42"
+)
+
+// SetCallerFormat sets how the default logger writes caller file paths
+func SetCallerFormat(format CallerFormat) {
+	if defaultLogger != nil {
+		defaultLogger.callerFormat = format
+	}
+}
+
+// AddCallerSkip makes the default logger report the caller n frames further
+// up the stack, so libraries wrapping emit report their own callers
+func AddCallerSkip(n int) {
+	if defaultLogger != nil {
+		defaultLogger.callerSkip += n
+	}
+}
+
+// caller returns the frame that logged the entry
+func (l *Logger) caller() (runtime.Frame, bool) {
+	return callerFrame(0, l.callerSkip)
+}
+
+// callerFile formats a caller's file with the logger's caller format
+func (l *Logger) callerFile(file string) string {
+	return callerPath(file, l.callerFormat)
+}
+
+// callerLine formats a caller as file:line with the logger's caller format
+func (l *Logger) callerLine(frame runtime.Frame) string {
+	return l.callerFile(frame.File) + ":" + strconv.Itoa(frame.Line)
+}
+
+// trimmedCallerLine formats a caller for human-readable output, where
+// full paths are trimmed unless another caller format was chosen
+func (l *Logger) trimmedCallerLine(frame runtime.Frame) string {
+	if l.callerFormat == CALLER_FULL {
+		return callerPath(frame.File, CALLER_TRIMMED) + ":" + strconv.Itoa(frame.Line)
+	}
+	return l.callerLine(frame)
+}
+
+// callerPath formats a file path in a caller format
+func callerPath(file string, format CallerFormat) string {
+	switch format {
+	case CALLER_TRIMMED:
+		return shortCaller(file)
+	case CALLER_SHORT:
+		return filepath.Base(file)
+	default:
+		return file
+	}
+}
+
+// callerFrame returns the first frame outside emit, skipping the given
+// number of frames below emit (such as hooks called from emit) and above
+// it (such as wrappers around emit)
+func callerFrame(skip, above int) (runtime.Frame, bool) {
+	var pcs [32]uintptr
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(skip+2, pcs[:])])
+
+	seenEmit := false
+	for {
+		frame, more := frames.Next()
+		if isEmitFrame(frame) || seenEmit && isStdLogFrame(frame) {
+			// Bridged standard library loggers call emit from package log
+			seenEmit = true
+		} else if seenEmit {
+			if above == 0 {
+				return frame, true
+			}
+			above--
+		}
+		if !more {
+			return runtime.Frame{}, false
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package emit
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// thisLine returns the line it was called from
+func thisLine() int {
+	_, _, line, _ := runtime.Caller(1)
+	return line
+}
+
+// TestCallerEveryAPI tests that every logging API reports the line calling it
+func TestCallerEveryAPI(t *testing.T) {
+	tests := []struct {
+		name string
+		log  func() int
+	}{
+		{"Info.Msg", func() int { Info.Msg("m"); return thisLine() }},
+		{"Info.Field", func() int { Info.Field("m", NewFields().String("k", "v")); return thisLine() }},
+		{"Info.KeyValue", func() int { Info.KeyValue("m", "k", "v"); return thisLine() }},
+		{"Info.StructuredFields", func() int { Info.StructuredFields("m", ZString("k", "v")); return thisLine() }},
+		{"Info.Pool", func() int { Info.Pool("m", func(pf *PooledFields) { pf.String("k", "v") }); return thisLine() }},
+		{"InfoMsg", func() int { InfoMsg("m"); return thisLine() }},
+		{"InfoWithFields", func() int { InfoWithFields("m", map[string]any{"k": "v"}); return thisLine() }},
+		{"InfoStructured", func() int { InfoStructured("m", ZInt("n", 1)); return thisLine() }},
+		{"Logger.InfoStructured", func() int { defaultLogger.InfoStructured("m"); return thisLine() }},
+		{"Log", func() int { Log("info", "m"); return thisLine() }},
+		{"JSON", func() int { JSON("info", "m"); return thisLine() }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			useTestLogger(t, &buf, withFormat(JSON_FORMAT), withComponent("api", ""), withCaller())
+			want := tt.log()
+
+			var entry struct {
+				File string `json:"file"`
+				Line int    `json:"line"`
+			}
+			if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+				t.Fatalf("Invalid JSON %q: %v", buf.String(), err)
+			}
+			if filepath.Base(entry.File) != "caller_test.go" || entry.Line != want {
+				t.Errorf("Expected caller_test.go:%d, got %s:%d in %s", want, entry.File, entry.Line, buf.String())
+			}
+		})
+	}
+}
+
+// TestCallerTextFormats tests the caller in plain, logfmt and console output
+func TestCallerTextFormats(t *testing.T) {
+	for _, format := range []OutputFormat{PLAIN_FORMAT, LOGFMT_FORMAT, CONSOLE_FORMAT} {
+		var buf bytes.Buffer
+		useTestLogger(t, &buf, withFormat(format), withComponent("api", ""), withCaller())
+
+		msgLine := thisLine() + 1
+		Info.Msg("m")
+		fieldsLine := thisLine() + 1
+		Info.KeyValue("m", "k", "v")
+		structuredLine := thisLine() + 1
+		Info.StructuredFields("m", ZString("k", "v"))
+
+		// Plain structured entries are written as JSON
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		for i, want := range []int{msgLine, fieldsLine, structuredLine} {
+			if i >= len(lines) || !strings.Contains(lines[i], "caller_test.go:"+strconv.Itoa(want)) && !strings.Contains(lines[i], `"line":`+strconv.Itoa(want)) {
+				t.Errorf("Format %d: expected caller_test.go:%d in %q", format, want, buf.String())
+			}
+		}
+	}
+}
+
+// logThroughWrapper is a library function wrapping emit, returning the
+// line that logs
+func logThroughWrapper(message string) int {
+	Info.Msg(message)
+	return thisLine() - 1
+}
+
+// TestAddCallerSkip tests that wrappers can report their own callers
+func TestAddCallerSkip(t *testing.T) {
+	var buf bytes.Buffer
+	testLogger := useTestLogger(t, &buf, withFormat(LOGFMT_FORMAT), withComponent("api", ""), withCaller())
+
+	wrapperLine := logThroughWrapper("unwrapped")
+	AddCallerSkip(1)
+	want := thisLine() + 1
+	logThroughWrapper("wrapped")
+
+	if testLogger.callerSkip != 1 {
+		t.Fatalf("Expected a caller skip of 1, got %d", testLogger.callerSkip)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], "caller_test.go:"+strconv.Itoa(wrapperLine)) {
+		t.Errorf("Expected the wrapper's line without a skip, got %q", buf.String())
+	}
+	if len(lines) != 2 || !strings.Contains(lines[1], "caller_test.go:"+strconv.Itoa(want)) {
+		t.Errorf("Expected the wrapper's caller with a skip, got %q", buf.String())
+	}
+}
+
+// TestCallerFormats tests the full, trimmed and short caller paths
+func TestCallerFormats(t *testing.T) {
+	tests := []struct {
+		format  CallerFormat
+		pattern string
+	}{
+		{CALLER_FULL, `caller=/\S+/caller_test\.go:\d+\n`},
+		{CALLER_TRIMMED, `caller=[^/\s]+/caller_test\.go:\d+\n`},
+		{CALLER_SHORT, `caller=caller_test\.go:\d+\n`},
+	}
+
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		useTestLogger(t, &buf, withFormat(LOGFMT_FORMAT), withComponent("api", ""), withCaller())
+		SetCallerFormat(tt.format)
+		Info.Msg("m")
+
+		if !regexp.MustCompile(tt.pattern).MatchString(buf.String()) {
+			t.Errorf("Format %d: %q does not match %s", tt.format, buf.String(), tt.pattern)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package emit
+
+import "maps"
+
+// Child is a logger derived from the default logger. Its entries carry its
+// component and fields, and otherwise follow the default logger's settings
+// at the time they are logged. Children are immutable and safe to share.
+type Child struct {
+	names  string         // Dotted names appended to the default component
+	fields map[string]any // Fields added to every entry
+	skip   int            // Frames between the caller and the child
+}
+
+// With returns a child of the default logger adding key-value pairs to
+// every entry
+func With(keysAndValues ...any) *Child {
+	return (&Child{}).With(keysAndValues...)
+}
+
+// Named returns a child of the default logger whose component is the
+// default component followed by name
+func Named(name string) *Child {
+	return (&Child{}).Named(name)
+}
+
+// With returns a child adding key-value pairs to every entry, replacing
+// this child's values for the same keys
+func (c *Child) With(keysAndValues ...any) *Child {
+	child := *c
+	child.fields = maps.Clone(c.fields)
+	if child.fields == nil {
+		child.fields = make(map[string]any, len(keysAndValues)/2)
+	}
+	maps.Copy(child.fields, parseKeyValuePairs(keysAndValues...))
+	return &child
+}
+
+// Named returns a child whose component has name appended, separated by
+// a dot ("api" becomes "api.reconciler")
+func (c *Child) Named(name string) *Child {
+	child := *c
+	if child.names != "" {
+		child.names += "." + name
+	} else {
+		child.names = name
+	}
+	return &child
+}
+
+// AddCallerSkip returns a child reporting callers n frames further up,
+// for wrappers logging through it
+func (c *Child) AddCallerSkip(n int) *Child {
+	child := *c
+	child.skip += n
+	return &child
+}
+
+// Enabled reports whether entries at level are written
+func (c *Child) Enabled(level LogLevel) bool {
+	return defaultLogger != nil && level >= defaultLogger.level
+}
+
+// Log logs at level with the child's fields and key-value pairs
+func (c *Child) Log(level LogLevel, message string, keysAndValues ...any) {
+	if c.Enabled(level) {
+		c.logFields(level, message, parseKeyValuePairs(keysAndValues...))
+	}
+}
+
+// LogFields logs at level with the child's fields and fields, for adapters
+// holding their fields in a map
+func (c *Child) LogFields(level LogLevel, message string, fields Fields) {
+	if c.Enabled(level) {
+		c.logFields(level, message, fields)
+	}
+}
+
+// logFields writes an entry with the child's component and fields
+func (c *Child) logFields(level LogLevel, message string, fields map[string]any) {
+	logger := *defaultLogger
+	if c.names != "" {
+		if logger.component != "" {
+			logger.component += "." + c.names
+		} else {
+			logger.component = c.names
+		}
+	}
+	logger.callerSkip += c.skip
+
+	if len(c.fields) > 0 {
+		merged := maps.Clone(c.fields)
+		maps.Copy(merged, fields)
+		fields = merged
+	}
+	logger.log(level, message, fields)
+}
+
+// Debug logs at DEBUG level with the child's fields and key-value pairs
+func (c *Child) Debug(message string, keysAndValues ...any) {
+	c.Log(DEBUG, message, keysAndValues...)
+}
+
+// Info logs at INFO level with the child's fields and key-value pairs
+func (c *Child) Info(message string, keysAndValues ...any) {
+	c.Log(INFO, message, keysAndValues...)
+}
+
+// Warn logs at WARN level with the child's fields and key-value pairs
+func (c *Child) Warn(message string, keysAndValues ...any) {
+	c.Log(WARN, message, keysAndValues...)
+}
+
+// Error logs at ERROR level with the child's fields and key-value pairs
+func (c *Child) Error(message string, keysAndValues ...any) {
+	c.Log(ERROR, message, keysAndValues...)
+}
@@ -0,0 +1,80 @@
+package emit
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// decodeChildEntries decodes JSON entries with their component and fields
+func decodeChildEntries(t *testing.T, output string) []LogEntry {
+	t.Helper()
+	var entries []LogEntry
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		var entry LogEntry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("Invalid JSON %q: %v", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// TestChildNamesAndFields tests the component hierarchy and inherited fields
+func TestChildNamesAndFields(t *testing.T) {
+	var buf bytes.Buffer
+	testLogger := useTestLogger(t, &buf, withFormat(JSON_FORMAT), withComponent("api", ""), withCaller())
+	testLogger.showCaller = false
+
+	controller := Named("controller").With("kind", "Pod")
+	reconciler := controller.Named("reconciler").With("kind", "Deployment", "password", "hunter2")
+	reconciler.Info("reconciled", "replicas", 3)
+	controller.Warn("requeued")
+	With("request_id", "r-1").Error("failed")
+
+	entries := decodeChildEntries(t, buf.String())
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 entries, got %q", buf.String())
+	}
+	if entries[0].Component != "api.controller.reconciler" || entries[1].Component != "api.controller" || entries[2].Component != "api" {
+		t.Errorf("Expected the component hierarchy, got %q", buf.String())
+	}
+	fields := entries[0].Fields
+	if fields["kind"] != "Deployment" || fields["replicas"] != float64(3) || fields["password"] != "***MASKED***" {
+		t.Errorf("Expected overridden, added and masked fields, got %v", fields)
+	}
+	if entries[1].Fields["kind"] != "Pod" || entries[1].Fields["password"] != nil {
+		t.Errorf("Expected the parent's fields unchanged, got %v", entries[1].Fields)
+	}
+	if entries[2].Level != "error" || entries[2].Fields["request_id"] != "r-1" {
+		t.Errorf("Expected an error entry with request_id, got %q", buf.String())
+	}
+}
+
+// TestChildLevelsAndCaller tests level filtering and caller reporting
+func TestChildLevelsAndCaller(t *testing.T) {
+	var buf bytes.Buffer
+	testLogger := useTestLogger(t, &buf, withFormat(JSON_FORMAT), withComponent("api", ""), withCaller())
+	testLogger.level = INFO
+
+	child := Named("worker")
+	if child.Enabled(DEBUG) || !child.Enabled(INFO) {
+		t.Errorf("Expected DEBUG disabled and INFO enabled")
+	}
+	child.Debug("filtered")
+	want := thisLine() + 1
+	child.Info("kept")
+
+	entries := decodeChildEntries(t, buf.String())
+	if len(entries) != 1 || entries[0].Message != "kept" {
+		t.Fatalf("Expected only the INFO entry, got %q", buf.String())
+	}
+	if filepath.Base(entries[0].File) != "child_test.go" || entries[0].Line != want {
+		t.Errorf("Expected child_test.go:%d, got %s:%d", want, entries[0].File, entries[0].Line)
+	}
+	if testLogger.component != "api" {
+		t.Errorf("Expected the default component unchanged, got %q", testLogger.component)
+	}
+}
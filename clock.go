@@ -0,0 +1,63 @@
+package emit
+
+import "time"
+
+// Clock supplies the time to a logger: entry timestamps, sampling windows,
+// rate limits, deduplication and write error retries. The emittest package
+// provides a fake clock for deterministic tests.
+type Clock interface {
+	Now() time.Time
+	NewTicker(d time.Duration) Ticker
+}
+
+// Ticker delivers a clock's ticks until stopped. It is an alias of an
+// unnamed interface so that clocks outside emit can return it without
+// importing emit.
+type Ticker = interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// systemClock is the real clock
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) NewTicker(d time.Duration) Ticker {
+	return systemTicker{time.NewTicker(d)}
+}
+
+// systemTicker is a real ticker
+type systemTicker struct {
+	*time.Ticker
+}
+
+func (t systemTicker) C() <-chan time.Time {
+	return t.Ticker.C
+}
+
+// SetClock sets the default logger's clock; nil restores the real clock.
+// Rate limiting keeps the clock it was enabled with, so set the clock first.
+func SetClock(clock Clock) {
+	if defaultLogger != nil {
+		defaultLogger.clock = clock
+	}
+}
+
+// now returns the current time from the logger's clock
+func (l *Logger) now() time.Time {
+	if l.clock == nil {
+		return time.Now()
+	}
+	return l.clock.Now()
+}
+
+// clockOrSystem returns the logger's clock, or the real clock when unset
+func (l *Logger) clockOrSystem() Clock {
+	if l.clock == nil {
+		return systemClock{}
+	}
+	return l.clock
+}
@@ -0,0 +1,84 @@
+package emit
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudresty/emit/emittest"
+)
+
+// TestClockGoldenOutput tests that every formatter takes its timestamps
+// from the logger's clock, giving byte-for-byte reproducible output
+func TestClockGoldenOutput(t *testing.T) {
+	setTestPrecision(t, MillisecondPrecision)
+
+	var buf bytes.Buffer
+	clock := emittest.NewClock(time.Date(2024, 3, 9, 8, 15, 30, 250000000, time.UTC))
+	testLogger := newTestLogger(&buf, withComponent("api", ""), withClock(clock))
+
+	testLogger.log(INFO, "simple", nil)
+	clock.Add(time.Millisecond)
+	testLogger.log(WARN, "map", map[string]any{"k": "v"})
+	clock.Add(time.Millisecond)
+	testLogger.logStructuredFields(ERROR, "structured", ZInt("n", 1))
+	clock.Add(time.Millisecond)
+	testLogger.format = PLAIN_FORMAT
+	testLogger.log(INFO, "plain", nil)
+	testLogger.format = LOGFMT_FORMAT
+	testLogger.log(INFO, "logfmt", nil)
+	testLogger.format = JSON_FORMAT
+	testLogger.profile = otelLayout
+	testLogger.log(INFO, "profile", nil)
+
+	want := `{"timestamp":"2024-03-09T08:15:30.250Z","level":"info","message":"simple","component":"api"}
+{"timestamp":"2024-03-09T08:15:30.251Z","level":"warn","message":"map","component":"api","fields":{"k":"v"}}
+{"timestamp":"2024-03-09T08:15:30.252Z","level":"error","message":"structured","n":1,"component":"api"}
+2024-03-09T08:15:30.253Z | info    | api : plain
+ts=2024-03-09T08:15:30.253Z level=info msg=logfmt component=api
+{"Timestamp":"2024-03-09T08:15:30.253Z","SeverityText":"INFO","SeverityNumber":9,"Body":"profile","Resource":{"service.name":"api"}}
+`
+	if buf.String() != want {
+		t.Errorf("Unexpected output:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+// TestClockSampling tests that sampling windows follow the logger's clock
+func TestClockSampling(t *testing.T) {
+	var buf bytes.Buffer
+	clock := emittest.NewClock(time.Date(2024, 3, 9, 8, 0, 0, 0, time.UTC))
+	testLogger := newTestLogger(&buf, withClock(clock), withSampling(SamplingConfig{Tick: time.Minute, First: 1}))
+
+	testLogger.log(INFO, "poll", nil)
+	clock.Add(59 * time.Second)
+	testLogger.log(INFO, "poll", nil)
+	clock.Add(time.Second)
+	testLogger.log(INFO, "poll", nil)
+
+	if got := strings.Count(buf.String(), "poll"); got != 2 {
+		t.Errorf("Expected one entry per sampling window, got %d:\n%s", got, buf.String())
+	}
+}
+
+// TestClockDedupExpiry tests that duplicate runs expire when the clock advances
+func TestClockDedupExpiry(t *testing.T) {
+	for _, mode := range []DedupMode{DEDUP_CONSECUTIVE, DEDUP_WINDOW} {
+		t.Run(fmt.Sprint(mode), func(t *testing.T) {
+			var buf syncBuffer
+			testLogger, clock := newRateLimitedTestLogger(t, &buf, RateLimitConfig{Dedup: mode, DedupWindow: time.Minute})
+
+			for i := 0; i < 3; i++ {
+				testLogger.log(WARN, "retrying", nil)
+			}
+			clock.Add(30 * time.Second)
+			if strings.Contains(buf.String(), "repeated") {
+				t.Fatalf("Run expired before its window:\n%s", buf.String())
+			}
+
+			clock.Add(time.Minute)
+			buf.waitFor(t, `"repeated":2`)
+		})
+	}
+}
@@ -0,0 +1,49 @@
+// Command cbor2json converts emit CBOR logs to JSON lines.
+//
+// Usage:
+//
+//	cbor2json [file ...]
+//
+// With no files it reads standard input.
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/cloudresty/emit"
+)
+
+func main() {
+	out := bufio.NewWriter(os.Stdout)
+	err := run(out, os.Args[1:])
+	if flushErr := out.Flush(); err == nil {
+		err = flushErr
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "cbor2json:", err)
+		os.Exit(1)
+	}
+}
+
+// run converts each named file, or standard input if there are none
+func run(out io.Writer, names []string) error {
+	if len(names) == 0 {
+		return emit.CBORToJSON(out, os.Stdin)
+	}
+
+	for _, name := range names {
+		file, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		err = emit.CBORToJSON(out, file)
+		file.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
@@ -1,9 +1,12 @@
 package emit
 
 import (
+	"errors"
+	"fmt"
 	"io"
 	"os"
 	"strings"
+	"time"
 )
 
 // initFromEnvironment initializes logger settings from environment variables
@@ -20,6 +23,15 @@ func initFromEnvironment() {
 		case "json", "production", "prod":
 			defaultLogger.format = JSON_FORMAT
 
+		case "logfmt":
+			defaultLogger.format = LOGFMT_FORMAT
+
+		case "cbor":
+			defaultLogger.format = CBOR_FORMAT
+
+		case "pretty":
+			defaultLogger.format = CONSOLE_FORMAT
+
 		default:
 			// Invalid value, stick with JSON default
 			defaultLogger.format = JSON_FORMAT
@@ -28,6 +40,18 @@ func initFromEnvironment() {
 
 	}
 
+	// Check for a JSON schema profile (ecs, otel, gcp, cloudwatch)
+	if profile := os.Getenv("EMIT_PROFILE"); profile != "" {
+		defaultLogger.profile = parseProfile(profile)
+	}
+
+	// Check for a plain text layout template (invalid templates are ignored)
+	if template := os.Getenv("EMIT_PLAIN_TEMPLATE"); template != "" {
+		if layout, err := parsePlainTemplate(template); err == nil {
+			defaultLogger.template = layout
+		}
+	}
+
 	// Also check for log level from environment
 	if logLevel := os.Getenv("EMIT_LEVEL"); logLevel != "" {
 		defaultLogger.level = ParseLogLevel(logLevel)
@@ -37,6 +61,19 @@ func initFromEnvironment() {
 	if showCaller := os.Getenv("EMIT_SHOW_CALLER"); showCaller != "" {
 		defaultLogger.showCaller = strings.ToLower(showCaller) == "true" || showCaller == "1"
 	}
+	if stackLevel := os.Getenv("EMIT_STACK_LEVEL"); stackLevel != "" {
+		SetStackTrace(StackTraceConfig{Level: ParseLogLevel(stackLevel)})
+	}
+	if enrich := os.Getenv("EMIT_ENRICH"); strings.ToLower(enrich) == "true" || enrich == "1" {
+		SetEnrichment(DefaultEnrichment)
+	}
+
+	switch strings.ToLower(os.Getenv("EMIT_CALLER_FORMAT")) {
+	case "trimmed":
+		defaultLogger.callerFormat = CALLER_TRIMMED
+	case "short":
+		defaultLogger.callerFormat = CALLER_SHORT
+	}
 
 	// Check for sensitive data masking setting
 	if sensitiveMode := os.Getenv("EMIT_MASK_SENSITIVE"); sensitiveMode != "" {
@@ -87,6 +124,12 @@ func initFromEnvironment() {
 	if timestampPrecision := os.Getenv("EMIT_TIMESTAMP_PRECISION"); timestampPrecision != "" {
 		SetTimestampPrecisionConfig(ParseTimestampPrecision(timestampPrecision))
 	}
+
+	if timeFormat := os.Getenv("EMIT_TIME_FORMAT"); timeFormat != "" {
+		if encoder, err := ParseTimeEncoder(timeFormat); err == nil {
+			SetTimeEncoder(encoder)
+		}
+	}
 }
 
 // SetComponent sets the component name for the default logger
@@ -117,7 +160,7 @@ func SetShowCaller(show bool) {
 	}
 }
 
-// SetFormat sets the output format (JSON or Plain)
+// SetFormat sets the output format (JSON, Plain, logfmt, CBOR or the pretty console)
 func SetFormat(format string) {
 
 	if defaultLogger != nil {
@@ -130,6 +173,15 @@ func SetFormat(format string) {
 		case "json":
 			defaultLogger.format = JSON_FORMAT
 
+		case "logfmt":
+			defaultLogger.format = LOGFMT_FORMAT
+
+		case "cbor":
+			defaultLogger.format = CBOR_FORMAT
+
+		case "pretty":
+			defaultLogger.format = CONSOLE_FORMAT
+
 		default:
 			defaultLogger.format = JSON_FORMAT
 
@@ -188,6 +240,14 @@ func SetOutput(writer io.Writer) {
 	}
 }
 
+// GetOutput returns the default logger's output writer
+func GetOutput() io.Writer {
+	if defaultLogger == nil {
+		return nil
+	}
+	return defaultLogger.writer
+}
+
 // SetOutputToDiscard redirects output to discard for benchmarking
 func SetOutputToDiscard() {
 	if defaultLogger != nil {
@@ -312,6 +372,32 @@ func ParseTimestampPrecision(precision string) TimestampPrecision {
 	}
 }
 
+// ParseTimeEncoder parses a timestamp format: rfc3339 (UTC), local, epoch,
+// epoch_ms, epoch_ns, or layout: followed by a Go time layout (in UTC), such
+// as "layout:2006-01-02 15:04:05"
+func ParseTimeEncoder(format string) (TimeEncoder, error) {
+	if layout, ok := strings.CutPrefix(format, "layout:"); ok {
+		if layout == "" {
+			return TimeEncoder{}, errors.New("emit: empty time layout")
+		}
+		return TimeEncoder{Format: TIME_LAYOUT, Layout: layout}, nil
+	}
+
+	switch strings.ToLower(format) {
+	case "rfc3339", "utc":
+		return TimeEncoder{}, nil
+	case "local":
+		return TimeEncoder{Location: time.Local}, nil
+	case "epoch", "unix":
+		return TimeEncoder{Format: TIME_EPOCH_SECONDS}, nil
+	case "epoch_ms", "unix_ms":
+		return TimeEncoder{Format: TIME_EPOCH_MILLIS}, nil
+	case "epoch_ns", "unix_ns":
+		return TimeEncoder{Format: TIME_EPOCH_NANOS}, nil
+	}
+	return TimeEncoder{}, fmt.Errorf("emit: unknown time format %q", format)
+}
+
 // SetTimestampPrecisionConfig sets the timestamp precision for the logging system
 func SetTimestampPrecisionConfig(precision TimestampPrecision) {
 	SetTimestampPrecision(precision)
@@ -113,8 +113,17 @@ func JSON(severity, message string, optionalParams ...string) {
 		SetVersion(optionalParams[1])
 	}
 
+	if !defaultLogger.admit(logLevel, message) {
+		return
+	}
+
+	message, fields, ok := defaultLogger.runHooks(logLevel, message, nil)
+	if !ok {
+		return
+	}
+
 	// Force JSON format for this call
-	defaultLogger.logJSON(logLevel, message, nil)
+	defaultLogger.logJSON(logLevel, message, fields)
 }
 
 // Plain forces plain output for a single log entry (for special cases)
@@ -129,6 +138,15 @@ func Plain(severity, message string, optionalParams ...string) {
 		SetVersion(optionalParams[1])
 	}
 
+	if !defaultLogger.admit(logLevel, message) {
+		return
+	}
+
+	message, fields, ok := defaultLogger.runHooks(logLevel, message, nil)
+	if !ok {
+		return
+	}
+
 	// Force plain format for this call
-	defaultLogger.logPlain(logLevel, message, nil)
+	defaultLogger.logPlain(logLevel, message, fields)
 }
@@ -0,0 +1,129 @@
+// Package emittest provides helpers for testing code that logs with emit:
+// a fake clock for deterministic timestamps and a recorder decoding the
+// entries written.
+//
+//	clock := emittest.NewClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+//	emit.SetClock(clock)
+//	defer emit.SetClock(nil)
+//
+//	emit.Info.Msg("started") // "timestamp":"2024-01-01T00:00:00.000Z"
+//	clock.Add(1500 * time.Millisecond)
+//	emit.Info.Msg("ready")   // "timestamp":"2024-01-01T00:00:01.500Z"
+package emittest
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// Clock is a fake emit.Clock whose time only moves when it is advanced.
+// Tickers created from it fire as Add or Set moves the time past their
+// next tick, dropping ticks the receiver has not consumed like time.Ticker.
+type Clock struct {
+	mu      sync.Mutex
+	now     time.Time
+	tickers []*Ticker
+}
+
+// Ticker is a ticker driven by a fake clock
+type Ticker struct {
+	clock  *Clock
+	period time.Duration
+	next   time.Time
+	c      chan time.Time
+}
+
+// NewClock returns a fake clock set to start
+func NewClock(start time.Time) *Clock {
+	return &Clock{now: start}
+}
+
+// Now returns the clock's current time
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// NewTicker returns a *Ticker firing every d of fake time, as the ticker
+// interface emit.Clock requires
+func (c *Clock) NewTicker(d time.Duration) interface {
+	C() <-chan time.Time
+	Stop()
+} {
+	return c.newTicker(d)
+}
+
+// newTicker starts a ticker firing every d
+func (c *Clock) newTicker(d time.Duration) *Ticker {
+	if d <= 0 {
+		panic("emittest: non-positive interval for NewTicker")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := &Ticker{clock: c, period: d, next: c.now.Add(d), c: make(chan time.Time, 1)}
+	c.tickers = append(c.tickers, t)
+	return t
+}
+
+// C returns the channel receiving the ticks
+func (t *Ticker) C() <-chan time.Time {
+	return t.c
+}
+
+// Stop stops the ticker; no more ticks are sent, and one already sent
+// stays in the channel as with time.Ticker
+func (t *Ticker) Stop() {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	t.clock.tickers = slices.DeleteFunc(t.clock.tickers, func(other *Ticker) bool { return other == t })
+}
+
+// Reset restarts the ticker, stopped or not, with period d from the
+// clock's current time
+func (t *Ticker) Reset(d time.Duration) {
+	if d <= 0 {
+		panic("emittest: non-positive interval for Reset")
+	}
+
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	t.period = d
+	t.next = t.clock.now.Add(d)
+	if !slices.Contains(t.clock.tickers, t) {
+		t.clock.tickers = append(t.clock.tickers, t)
+	}
+}
+
+// Add advances the clock by d
+func (c *Clock) Add(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.set(c.now.Add(d))
+}
+
+// Set moves the clock to now, which may be in the past
+func (c *Clock) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.set(now)
+}
+
+// set moves the clock and fires due tickers; callers hold c.mu
+func (c *Clock) set(now time.Time) {
+	c.now = now
+	for _, t := range c.tickers {
+		if t.next.After(now) {
+			continue
+		}
+		select {
+		case t.c <- t.next:
+		default:
+		}
+		// Skip the ticks in between, which a full channel would drop
+		t.next = t.next.Add(t.period * (now.Sub(t.next)/t.period + 1))
+	}
+}
@@ -0,0 +1,77 @@
+package emittest
+
+import (
+	"testing"
+	"time"
+)
+
+// TestClockAdvance tests that the time only moves when advanced
+func TestClockAdvance(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewClock(start)
+
+	if !clock.Now().Equal(start) {
+		t.Fatalf("Expected %v, got %v", start, clock.Now())
+	}
+	clock.Add(90 * time.Second)
+	if want := start.Add(90 * time.Second); !clock.Now().Equal(want) {
+		t.Errorf("Expected %v, got %v", want, clock.Now())
+	}
+	clock.Set(start)
+	if !clock.Now().Equal(start) {
+		t.Errorf("Expected Set to move the clock back to %v, got %v", start, clock.Now())
+	}
+}
+
+// TestClockTicker tests that tickers fire as the clock passes their ticks
+func TestClockTicker(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewClock(start)
+	ticker := clock.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	clock.Add(999 * time.Millisecond)
+	select {
+	case tick := <-ticker.C():
+		t.Fatalf("Unexpected tick at %v", tick)
+	default:
+	}
+
+	clock.Add(time.Millisecond)
+	if tick := <-ticker.C(); !tick.Equal(start.Add(time.Second)) {
+		t.Errorf("Expected a tick at 1s, got %v", tick)
+	}
+
+	// Missed ticks are dropped, and the next one stays on the period
+	clock.Add(time.Hour + 500*time.Millisecond)
+	<-ticker.C()
+	clock.Add(500 * time.Millisecond)
+	if tick := <-ticker.C(); !tick.Equal(start.Add(time.Hour + 2*time.Second)) {
+		t.Errorf("Expected a tick at 1h2s, got %v", tick)
+	}
+}
+
+// TestTickerStopAndReset tests that stopped tickers stop firing and are
+// released by the clock, and that Reset restarts them
+func TestTickerStopAndReset(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewClock(start)
+	ticker := clock.newTicker(time.Second)
+
+	ticker.Stop()
+	clock.Add(time.Minute)
+	select {
+	case tick := <-ticker.C():
+		t.Fatalf("Unexpected tick at %v after Stop", tick)
+	default:
+	}
+	if len(clock.tickers) != 0 {
+		t.Errorf("Expected the clock to release stopped tickers, got %d", len(clock.tickers))
+	}
+
+	ticker.Reset(2 * time.Second)
+	clock.Add(2 * time.Second)
+	if tick := <-ticker.C(); !tick.Equal(start.Add(time.Minute + 2*time.Second)) {
+		t.Errorf("Expected a tick 2s after Reset, got %v", tick)
+	}
+}
@@ -0,0 +1,80 @@
+package emittest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Entry is a decoded JSON entry in emit's default schema
+type Entry struct {
+	Level     string         `json:"level"`
+	Message   string         `json:"message"`
+	Component string         `json:"component"`
+	File      string         `json:"file"`
+	Line      int            `json:"line"`
+	Fields    map[string]any `json:"fields"`
+}
+
+// Recorder collects the entries written to it, safe for concurrent loggers
+type Recorder struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+// Capture sends a logger's output to a new recorder until the test ends,
+// when the previous output is restored:
+//
+//	emit.SetJSONFormat()
+//	rec := emittest.Capture(t, emit.GetOutput, emit.SetOutput)
+//	emit.Info.Msg("started")
+//	entries := rec.Entries(t)
+func Capture(t testing.TB, getOutput func() io.Writer, setOutput func(io.Writer)) *Recorder {
+	rec := &Recorder{}
+	previous := getOutput()
+	setOutput(rec)
+	t.Cleanup(func() { setOutput(previous) })
+	return rec
+}
+
+// Write records entries
+func (r *Recorder) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.buf.Write(p)
+}
+
+// String returns everything written so far
+func (r *Recorder) String() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.buf.String()
+}
+
+// Reset drops the entries written so far
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.buf.Reset()
+}
+
+// Entries decodes each JSON line written so far, failing the test on
+// invalid JSON
+func (r *Recorder) Entries(t testing.TB) []Entry {
+	t.Helper()
+	var entries []Entry
+	for _, line := range strings.Split(strings.TrimSpace(r.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("Invalid JSON %q: %v", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
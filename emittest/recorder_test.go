@@ -0,0 +1,50 @@
+package emittest
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+// TestRecorderEntries tests capturing and decoding entries
+func TestRecorderEntries(t *testing.T) {
+	var output io.Writer
+	rec := Capture(t, func() io.Writer { return output }, func(w io.Writer) { output = w })
+
+	io.WriteString(output, `{"level":"info","message":"a","component":"api","fields":{"k":"v"}}`+"\n")
+	io.WriteString(output, `{"level":"warn","message":"b","file":"main.go","line":7}`+"\n")
+
+	entries := rec.Entries(t)
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %q", rec.String())
+	}
+	if entries[0].Component != "api" || entries[0].Fields["k"] != "v" {
+		t.Errorf("Expected the component and fields, got %+v", entries[0])
+	}
+	if entries[1].Level != "warn" || entries[1].File != "main.go" || entries[1].Line != 7 {
+		t.Errorf("Expected the level and caller, got %+v", entries[1])
+	}
+
+	rec.Reset()
+	if entries := rec.Entries(t); len(entries) != 0 {
+		t.Errorf("Expected no entries after Reset, got %+v", entries)
+	}
+}
+
+// TestCaptureRestoresOutput tests that the previous writer is restored when
+// the test ends
+func TestCaptureRestoresOutput(t *testing.T) {
+	previous := io.Writer(new(strings.Builder))
+	output := previous
+
+	t.Run("capture", func(t *testing.T) {
+		Capture(t, func() io.Writer { return output }, func(w io.Writer) { output = w })
+		if output == previous {
+			t.Fatal("Expected the output to be the recorder")
+		}
+	})
+
+	if output != previous {
+		t.Errorf("Expected the previous writer restored, got %T", output)
+	}
+}
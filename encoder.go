@@ -0,0 +1,332 @@
+package emit
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// entryEncoder appends the parts of one entry in an output format.
+// Formats other than the default JSON fast paths are built on it.
+type entryEncoder interface {
+	begin(buf []byte) []byte
+	end(buf []byte) []byte
+
+	appendString(buf []byte, key, value string) []byte
+	appendInt(buf []byte, key string, value int64) []byte
+	appendFloat(buf []byte, key string, value float64) []byte
+	appendBool(buf []byte, key string, value bool) []byte
+	appendTime(buf []byte, key string, value time.Time) []byte
+	appendDuration(buf []byte, key string, value time.Duration) []byte
+
+	// appendEntryTime writes the entry timestamp with the logger's time encoder
+	appendEntryTime(buf []byte, key string, te *timeEncoder, now time.Time) []byte
+
+	// appendAny encodes values without a typed method (including nil)
+	appendAny(buf []byte, key string, value any) []byte
+
+	// appendStack writes a stack trace
+	appendStack(buf []byte, key string, frames []stackFrame) []byte
+}
+
+// entryKeys names the built-in keys of an entry
+type entryKeys struct {
+	time      string
+	level     string
+	message   string
+	component string
+	version   string
+	caller    string // file:line in one key (keyed layouts)
+	file      string // Caller split into file, line and function (LogEntry layouts)
+	line      string
+	function  string
+	fields    string // Object holding map fields (LogEntry layouts)
+}
+
+// entryShape selects how a layout arranges an entry
+type entryShape int
+
+const (
+	shapeKeyed      entryShape = iota // Built-in keys then the fields, at the top level
+	shapeECS                          // Elastic Common Schema
+	shapeOTel                         // OpenTelemetry logs data model
+	shapeLogEntry                     // LogEntry with renamed keys, map fields nested
+	shapeGCP                          // Google Cloud Logging structured JSON
+	shapeCloudWatch                   // Keyed JSON with CloudWatch Embedded Metric Format
+	shapeConsole                      // Developer console lines
+	shapeTemplate                     // Plain text laid out by a template
+)
+
+// entryLayout describes how entries are arranged and encoded. It is a
+// concrete type so the variadic structured fields never escape through
+// an interface call.
+type entryLayout struct {
+	enc   entryEncoder
+	keys  entryKeys
+	shape entryShape
+
+	gcpProject string        // Project prefixed to trace IDs (shapeGCP)
+	emf        *emfDirective // Metrics declared in entries (shapeCloudWatch)
+	console    *consoleOptions
+	template   []templatePart // Compiled plain layout (shapeTemplate)
+
+	levels LevelEncoding // How levels are written (shapeLogEntry)
+	fields fieldLayout   // Where user fields go (shapeLogEntry)
+	filter *fieldFilter  // Collision handling of flat user fields (shapeLogEntry)
+}
+
+// fieldFilter adjusts the keys of top-level user fields as they are
+// written. A nil filter writes every field under its own key.
+type fieldFilter struct {
+	skip      []string // Fields consumed by the layout itself
+	reserved  []string // Built-in keys user fields must not shadow
+	collision CollisionPolicy
+	prefix    string // Prepended to colliding keys (COLLISION_PREFIX)
+}
+
+// key returns the key to write a field under, or false to leave it out
+func (f *fieldFilter) key(key string) (string, bool) {
+	if f == nil {
+		return key, true
+	}
+	if len(f.skip) > 0 && slices.Contains(f.skip, key) {
+		return "", false
+	}
+	if len(f.reserved) == 0 || !slices.Contains(f.reserved, key) {
+		return key, true
+	}
+
+	switch f.collision {
+	case COLLISION_DROP:
+		return "", false
+	case COLLISION_KEEP:
+		return key, true
+	default:
+		return f.prefix + key, true
+	}
+}
+
+// formatLayout returns the layout of output formats written through an
+// entryEncoder (nil for the JSON and built-in plain formatters)
+func (l *Logger) formatLayout() *entryLayout {
+	switch l.format {
+	case PLAIN_FORMAT:
+		return l.template
+	case LOGFMT_FORMAT:
+		return logfmtLayout
+	case CBOR_FORMAT:
+		return cborLayout
+	case CONSOLE_FORMAT:
+		return l.consoleLayout()
+	default:
+		return nil
+	}
+}
+
+// Largest pooled buffer kept after an entry grew it
+const maxPooledBuffer = 64 << 10
+
+// encodeEntry encodes and writes an entry with a layout. Exactly one of
+// fields and zfields is used, matching the API that logged the entry.
+func (l *Logger) encodeEntry(layout *entryLayout, level LogLevel, message string, fields map[string]any, zfields []ZField) {
+	start := l.encodeStart()
+
+	bufPtr := bufferPool.Get().(*[]byte)
+	defer bufferPool.Put(bufPtr)
+
+	buf, masked := layout.appendEntry(l, (*bufPtr)[:0], level, message, fields, zfields)
+
+	l.fieldsMasked(level, masked)
+	l.write(level, buf, start)
+
+	if cap(buf) <= maxPooledBuffer {
+		*bufPtr = buf[:cap(buf)]
+	}
+}
+
+// appendEntry appends a whole entry, returning the number of masked fields
+func (k *entryLayout) appendEntry(l *Logger, buf []byte, level LogLevel, message string, fields map[string]any, zfields []ZField) ([]byte, int) {
+	switch k.shape {
+	case shapeECS:
+		return k.appendECS(l, buf, level, message, fields, zfields)
+	case shapeOTel:
+		return k.appendOTel(l, buf, level, message, fields, zfields)
+	case shapeLogEntry:
+		return k.appendLogEntry(l, buf, level, message, fields, zfields)
+	case shapeGCP:
+		return k.appendGCP(l, buf, level, message, fields, zfields)
+	case shapeCloudWatch:
+		return k.appendCloudWatch(l, buf, level, message, fields, zfields)
+	case shapeConsole:
+		return k.appendConsole(l, buf, level, message, fields, zfields)
+	case shapeTemplate:
+		return k.appendTemplate(l, buf, level, message, fields, zfields)
+	default:
+		return k.appendKeyed(l, buf, level, message, fields, zfields)
+	}
+}
+
+// appendKeyed appends the built-in keys then the fields
+func (k *entryLayout) appendKeyed(l *Logger, buf []byte, level LogLevel, message string, fields map[string]any, zfields []ZField) ([]byte, int) {
+	buf, masked := k.appendKeyedBody(l, k.enc.begin(buf), level, message, fields, zfields)
+	return k.enc.end(buf), masked
+}
+
+// appendKeyedBody appends the keyed layout inside an already open entry
+func (k *entryLayout) appendKeyedBody(l *Logger, buf []byte, level LogLevel, message string, fields map[string]any, zfields []ZField) ([]byte, int) {
+	enc := k.enc
+	buf = enc.appendEntryTime(buf, k.keys.time, l.times(), l.now())
+	buf = enc.appendString(buf, k.keys.level, level.StringFast())
+	buf = enc.appendString(buf, k.keys.message, message)
+
+	if l.component != "" {
+		buf = enc.appendString(buf, k.keys.component, l.component)
+	}
+	if l.version != "" {
+		buf = enc.appendString(buf, k.keys.version, l.version)
+	}
+	if l.showCaller {
+		if frame, ok := l.caller(); ok {
+			buf = enc.appendString(buf, k.keys.caller, l.callerLine(frame))
+		}
+	}
+
+	buf, masked := l.appendUserFields(enc, buf, fields, zfields, nil)
+	return l.appendStack(enc, l.appendEnrichment(enc, buf), level), masked
+}
+
+// appendUserFields appends the entry fields with masking applied, keyed
+// through filter, and returns the number masked
+func (l *Logger) appendUserFields(enc entryEncoder, buf []byte, fields map[string]any, zfields []ZField, filter *fieldFilter) ([]byte, int) {
+	if zfields != nil {
+		return appendZFields(enc, buf, zfields, filter)
+	}
+	if len(fields) == 0 {
+		return buf, 0
+	}
+
+	masked := 0
+	if l.metrics != nil {
+		masked = l.countMaskedFields(fields)
+	}
+	return appendFields(enc, buf, "", l.maskSensitiveFieldsFast(fields), filter), masked
+}
+
+// appendZFields appends structured fields in call order, masking them
+// exactly like the JSON structured path
+func appendZFields(enc entryEncoder, buf []byte, fields []ZField, filter *fieldFilter) ([]byte, int) {
+	masked := 0
+	for _, field := range fields {
+		key, ok := filter.key(zfieldKey(field))
+		if !ok {
+			continue
+		}
+
+		switch f := field.(type) {
+		case StringZField:
+			if f.IsSensitive() || f.IsPII() {
+				buf = enc.appendString(buf, key, zfieldMask)
+				masked++
+			} else {
+				buf = enc.appendString(buf, key, f.Value)
+			}
+		case IntZField:
+			buf = enc.appendInt(buf, key, int64(f.Value))
+		case Int64ZField:
+			buf = enc.appendInt(buf, key, f.Value)
+		case Float64ZField:
+			buf = enc.appendFloat(buf, key, f.Value)
+		case BoolZField:
+			buf = enc.appendBool(buf, key, f.Value)
+		case TimeZField:
+			buf = enc.appendTime(buf, key, f.Value)
+		case DurationZField:
+			buf = enc.appendDuration(buf, key, f.Value)
+		}
+	}
+	return buf, masked
+}
+
+// appendFields appends (already masked) map fields sorted by key. Nested
+// maps become nested objects, or dotted keys if the encoder cannot nest.
+func appendFields(enc entryEncoder, buf []byte, prefix string, fields map[string]any, filter *fieldFilter) []byte {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	objects, nests := enc.(objectEncoder)
+	for _, name := range keys {
+		value := fields[name]
+		key, ok := filter.key(name)
+		if !ok {
+			continue
+		}
+		if nested, ok := value.(map[string]any); ok {
+			if nests {
+				buf = objects.openObject(buf, key)
+				buf = appendFields(enc, buf, "", nested, nil)
+				buf = objects.closeObject(buf)
+			} else {
+				buf = appendFields(enc, buf, prefix+key+".", nested, nil)
+			}
+			continue
+		}
+		buf = appendValue(enc, buf, prefix+key, value)
+	}
+	return buf
+}
+
+// appendValue dispatches a value to the matching typed encoder method
+func appendValue(enc entryEncoder, buf []byte, key string, value any) []byte {
+	switch v := value.(type) {
+	case string:
+		return enc.appendString(buf, key, v)
+	case int:
+		return enc.appendInt(buf, key, int64(v))
+	case int8:
+		return enc.appendInt(buf, key, int64(v))
+	case int16:
+		return enc.appendInt(buf, key, int64(v))
+	case int32:
+		return enc.appendInt(buf, key, int64(v))
+	case int64:
+		return enc.appendInt(buf, key, v)
+	case uint:
+		return appendUint(enc, buf, key, uint64(v))
+	case uint8:
+		return enc.appendInt(buf, key, int64(v))
+	case uint16:
+		return enc.appendInt(buf, key, int64(v))
+	case uint32:
+		return enc.appendInt(buf, key, int64(v))
+	case uint64:
+		return appendUint(enc, buf, key, v)
+	case float32:
+		return enc.appendFloat(buf, key, float64(v))
+	case float64:
+		return enc.appendFloat(buf, key, v)
+	case bool:
+		return enc.appendBool(buf, key, v)
+	case time.Time:
+		return enc.appendTime(buf, key, v)
+	case time.Duration:
+		return enc.appendDuration(buf, key, v)
+	case error:
+		return enc.appendString(buf, key, v.Error())
+	case fmt.Stringer:
+		return enc.appendString(buf, key, v.String())
+	default:
+		return enc.appendAny(buf, key, v)
+	}
+}
+
+// appendUint encodes unsigned values that may not fit an int64 as strings
+func appendUint(enc entryEncoder, buf []byte, key string, value uint64) []byte {
+	if value > 1<<63-1 {
+		return enc.appendString(buf, key, strconv.FormatUint(value, 10))
+	}
+	return enc.appendInt(buf, key, int64(value))
+}
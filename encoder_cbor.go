@@ -0,0 +1,273 @@
+package emit
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"time"
+)
+
+// CBOR major types (RFC 8949 section 3.1)
+const (
+	cborUint   byte = 0 << 5
+	cborNegint byte = 1 << 5
+	cborBytes  byte = 2 << 5
+	cborText   byte = 3 << 5
+	cborArray  byte = 4 << 5
+	cborMap    byte = 5 << 5
+	cborTag    byte = 6 << 5
+	cborSimple byte = 7 << 5
+)
+
+// CBOR simple values, floats and markers
+const (
+	cborFalse      byte = 0xf4
+	cborTrue       byte = 0xf5
+	cborNull       byte = 0xf6
+	cborUndefined  byte = 0xf7
+	cborFloat16    byte = 0xf9
+	cborFloat32    byte = 0xfa
+	cborFloat64    byte = 0xfb
+	cborBreak      byte = 0xff
+	cborIndefinite byte = 31
+)
+
+// CBOR tags written by the encoder
+const (
+	cborTagDateTime = 0 // RFC 3339 text
+	cborTagEpoch    = 1 // Seconds since the epoch
+)
+
+// cborLayout writes emit's keys then the fields, at the top level
+var cborLayout = &entryLayout{enc: cborEncoder{}, keys: logEntryKeys}
+
+// cborEncoder writes each entry as one indefinite-length CBOR map (RFC 8949),
+// so a stream of entries is a CBOR sequence (RFC 8742) with no separators.
+// Times are tagged RFC 3339 strings, durations are nanoseconds like JSON.
+type cborEncoder struct{}
+
+func (cborEncoder) begin(buf []byte) []byte {
+	return append(buf, cborMap|cborIndefinite)
+}
+
+func (cborEncoder) end(buf []byte) []byte {
+	return append(buf, cborBreak)
+}
+
+func (cborEncoder) openObject(buf []byte, key string) []byte {
+	buf = appendCBORText(buf, key)
+	return append(buf, cborMap|cborIndefinite)
+}
+
+func (cborEncoder) closeObject(buf []byte) []byte {
+	return append(buf, cborBreak)
+}
+
+func (cborEncoder) appendString(buf []byte, key, value string) []byte {
+	buf = appendCBORText(buf, key)
+	return appendCBORText(buf, value)
+}
+
+func (cborEncoder) appendInt(buf []byte, key string, value int64) []byte {
+	buf = appendCBORText(buf, key)
+	return appendCBORInt(buf, value)
+}
+
+func (cborEncoder) appendFloat(buf []byte, key string, value float64) []byte {
+	buf = appendCBORText(buf, key)
+	return appendCBORFloat(buf, value)
+}
+
+func (cborEncoder) appendBool(buf []byte, key string, value bool) []byte {
+	buf = appendCBORText(buf, key)
+	return appendCBORBool(buf, value)
+}
+
+func (cborEncoder) appendTime(buf []byte, key string, value time.Time) []byte {
+	buf = appendCBORText(buf, key)
+	return appendCBORTime(buf, value)
+}
+
+// appendEntryTime writes epoch timestamps as numbers and others as text
+func (cborEncoder) appendEntryTime(buf []byte, key string, te *timeEncoder, now time.Time) []byte {
+	buf = appendCBORText(buf, key)
+	switch te.format {
+	case TIME_EPOCH_SECONDS:
+		if GetTimestampPrecision() == SecondPrecision {
+			return appendCBORInt(buf, now.Unix())
+		}
+		return appendCBORFloat(buf, float64(now.UnixNano())/float64(time.Second))
+	case TIME_EPOCH_MILLIS:
+		return appendCBORInt(buf, now.UnixMilli())
+	case TIME_EPOCH_NANOS:
+		return appendCBORInt(buf, now.UnixNano())
+	}
+
+	// The text head needs the length, so format into a scratch buffer first
+	var scratch [64]byte
+	timestamp := te.append(scratch[:0], now)
+	buf = appendCBORHead(buf, cborText, uint64(len(timestamp)))
+	return append(buf, timestamp...)
+}
+
+func (cborEncoder) appendDuration(buf []byte, key string, value time.Duration) []byte {
+	buf = appendCBORText(buf, key)
+	return appendCBORInt(buf, int64(value))
+}
+
+// appendStack writes an array of {function, file, line} maps
+func (cborEncoder) appendStack(buf []byte, key string, frames []stackFrame) []byte {
+	buf = appendCBORText(buf, key)
+	buf = appendCBORHead(buf, cborArray, uint64(len(frames)))
+	for _, frame := range frames {
+		buf = appendCBORHead(buf, cborMap, 3)
+		buf = appendCBORText(buf, "function")
+		buf = appendCBORText(buf, frame.function)
+		buf = appendCBORText(buf, "file")
+		buf = appendCBORText(buf, frame.file)
+		buf = appendCBORText(buf, "line")
+		buf = appendCBORInt(buf, int64(frame.line))
+	}
+	return buf
+}
+
+func (cborEncoder) appendAny(buf []byte, key string, value any) []byte {
+	buf = appendCBORText(buf, key)
+	return appendCBORValue(buf, value)
+}
+
+// appendCBORHead appends an item head: the major type and its argument
+// in the shortest form
+func appendCBORHead(buf []byte, major byte, n uint64) []byte {
+	switch {
+	case n < 24:
+		return append(buf, major|byte(n))
+	case n <= math.MaxUint8:
+		return append(buf, major|24, byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(buf, major|25), uint16(n))
+	case n <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(buf, major|26), uint32(n))
+	default:
+		return binary.BigEndian.AppendUint64(append(buf, major|27), n)
+	}
+}
+
+func appendCBORText(buf []byte, s string) []byte {
+	buf = appendCBORHead(buf, cborText, uint64(len(s)))
+	return append(buf, s...)
+}
+
+func appendCBORInt(buf []byte, value int64) []byte {
+	if value < 0 {
+		return appendCBORHead(buf, cborNegint, uint64(-(value + 1)))
+	}
+	return appendCBORHead(buf, cborUint, uint64(value))
+}
+
+// appendCBORFloat uses single precision when it holds the value exactly
+func appendCBORFloat(buf []byte, value float64) []byte {
+	if single := float32(value); float64(single) == value || math.IsNaN(value) {
+		return binary.BigEndian.AppendUint32(append(buf, cborFloat32), math.Float32bits(single))
+	}
+	return binary.BigEndian.AppendUint64(append(buf, cborFloat64), math.Float64bits(value))
+}
+
+func appendCBORBool(buf []byte, value bool) []byte {
+	if value {
+		return append(buf, cborTrue)
+	}
+	return append(buf, cborFalse)
+}
+
+func appendCBORTime(buf []byte, value time.Time) []byte {
+	var scratch [64]byte
+	buf = appendCBORHead(buf, cborTag, cborTagDateTime)
+	formatted := value.AppendFormat(scratch[:0], time.RFC3339Nano)
+	buf = appendCBORHead(buf, cborText, uint64(len(formatted)))
+	return append(buf, formatted...)
+}
+
+// appendCBORValue encodes values without a typed method, falling back to
+// their JSON form for types CBOR has no direct mapping for
+func appendCBORValue(buf []byte, value any) []byte {
+	switch v := value.(type) {
+	case nil:
+		return append(buf, cborNull)
+	case string:
+		return appendCBORText(buf, v)
+	case bool:
+		return appendCBORBool(buf, v)
+	case int:
+		return appendCBORInt(buf, int64(v))
+	case int8:
+		return appendCBORInt(buf, int64(v))
+	case int16:
+		return appendCBORInt(buf, int64(v))
+	case int32:
+		return appendCBORInt(buf, int64(v))
+	case int64:
+		return appendCBORInt(buf, v)
+	case uint:
+		return appendCBORHead(buf, cborUint, uint64(v))
+	case uint8:
+		return appendCBORHead(buf, cborUint, uint64(v))
+	case uint16:
+		return appendCBORHead(buf, cborUint, uint64(v))
+	case uint32:
+		return appendCBORHead(buf, cborUint, uint64(v))
+	case uint64:
+		return appendCBORHead(buf, cborUint, v)
+	case float32:
+		return appendCBORFloat(buf, float64(v))
+	case float64:
+		return appendCBORFloat(buf, v)
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return appendCBORInt(buf, n)
+		}
+		f, _ := v.Float64()
+		return appendCBORFloat(buf, f)
+	case []byte:
+		buf = appendCBORHead(buf, cborBytes, uint64(len(v)))
+		return append(buf, v...)
+	case time.Time:
+		return appendCBORTime(buf, v)
+	case time.Duration:
+		return appendCBORInt(buf, int64(v))
+	case []any:
+		buf = appendCBORHead(buf, cborArray, uint64(len(v)))
+		for _, item := range v {
+			buf = appendCBORValue(buf, item)
+		}
+		return buf
+	case map[string]any:
+		buf = appendCBORHead(buf, cborMap, uint64(len(v)))
+		for _, key := range slices.Sorted(maps.Keys(v)) {
+			buf = appendCBORText(buf, key)
+			buf = appendCBORValue(buf, v[key])
+		}
+		return buf
+	case error:
+		return appendCBORText(buf, v.Error())
+	case fmt.Stringer:
+		return appendCBORText(buf, v.String())
+	}
+
+	// Round trip anything else through encoding/json
+	data, err := json.Marshal(value)
+	if err != nil {
+		return appendCBORText(buf, fmt.Sprint(value))
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var generic any
+	if err := decoder.Decode(&generic); err != nil {
+		return appendCBORText(buf, string(data))
+	}
+	return appendCBORValue(buf, generic)
+}
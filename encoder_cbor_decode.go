@@ -0,0 +1,322 @@
+package emit
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"strconv"
+	"time"
+)
+
+// Limits guarding CBORToJSON against corrupt or hostile input
+const (
+	cborMaxDepth  = 64
+	cborMaxLength = 64 << 20
+)
+
+// CBORToJSON converts a CBOR sequence, such as the output of CBOR_FORMAT,
+// to JSON with one object per line. Byte strings become base64 strings,
+// epoch times become RFC 3339 strings and other tags are dropped.
+func CBORToJSON(dst io.Writer, src io.Reader) error {
+	d := cborDecoder{r: bufio.NewReader(src)}
+
+	var buf []byte
+	for {
+		if _, err := d.r.Peek(1); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		var err error
+		if buf, err = d.appendItem(buf[:0], 0); err != nil {
+			return err
+		}
+		buf = append(buf, '\n')
+		if _, err := dst.Write(buf); err != nil {
+			return err
+		}
+	}
+}
+
+// cborDecoder reads CBOR items and appends them as JSON
+type cborDecoder struct {
+	r *bufio.Reader
+}
+
+// appendItem decodes one data item
+func (d *cborDecoder) appendItem(buf []byte, depth int) ([]byte, error) {
+	if depth > cborMaxDepth {
+		return buf, errors.New("emit: CBOR nesting too deep")
+	}
+
+	initial, err := d.r.ReadByte()
+	if err != nil {
+		return buf, d.unexpected(err)
+	}
+	major, info := initial&0xe0, initial&0x1f
+
+	if major == cborSimple {
+		return d.appendSimple(buf, initial)
+	}
+
+	indefinite := info == cborIndefinite
+	var n uint64
+	if !indefinite {
+		if n, err = d.argument(info); err != nil {
+			return buf, err
+		}
+	} else if major == cborUint || major == cborNegint || major == cborTag {
+		return buf, fmt.Errorf("emit: invalid CBOR item 0x%02x", initial)
+	}
+
+	switch major {
+	case cborUint:
+		return strconv.AppendUint(buf, n, 10), nil
+
+	case cborNegint:
+		if n < 1<<63 {
+			return strconv.AppendInt(buf, -1-int64(n), 10), nil
+		}
+		value := new(big.Int).SetUint64(n)
+		return value.Neg(value.Add(value, big.NewInt(1))).Append(buf, 10), nil
+
+	case cborBytes:
+		data, err := d.readString(major, n, indefinite)
+		if err != nil {
+			return buf, err
+		}
+		buf = append(buf, '"')
+		buf = base64.StdEncoding.AppendEncode(buf, data)
+		return append(buf, '"'), nil
+
+	case cborText:
+		data, err := d.readString(major, n, indefinite)
+		if err != nil {
+			return buf, err
+		}
+		return appendJSONString(buf, string(data)), nil
+
+	case cborArray:
+		buf = append(buf, '[')
+		for i := uint64(0); indefinite || i < n; i++ {
+			if indefinite && d.atBreak() {
+				break
+			}
+			if i > 0 {
+				buf = append(buf, ',')
+			}
+			if buf, err = d.appendItem(buf, depth+1); err != nil {
+				return buf, err
+			}
+		}
+		return append(buf, ']'), nil
+
+	case cborMap:
+		buf = append(buf, '{')
+		for i := uint64(0); indefinite || i < n; i++ {
+			if indefinite && d.atBreak() {
+				break
+			}
+			if i > 0 {
+				buf = append(buf, ',')
+			}
+			if buf, err = d.appendKey(buf, depth+1); err != nil {
+				return buf, err
+			}
+			buf = append(buf, ':')
+			if buf, err = d.appendItem(buf, depth+1); err != nil {
+				return buf, err
+			}
+		}
+		return append(buf, '}'), nil
+
+	default: // cborTag
+		if n == cborTagEpoch {
+			return d.appendEpoch(buf, depth+1)
+		}
+		return d.appendItem(buf, depth+1)
+	}
+}
+
+// appendKey writes a map key, quoting the JSON of keys that are not text
+func (d *cborDecoder) appendKey(buf []byte, depth int) ([]byte, error) {
+	key, err := d.appendItem(nil, depth)
+	if err != nil {
+		return buf, err
+	}
+	if len(key) > 0 && key[0] == '"' {
+		return append(buf, key...), nil
+	}
+	return appendJSONString(buf, string(key)), nil
+}
+
+// appendEpoch writes a tag 1 epoch time as an RFC 3339 string
+func (d *cborDecoder) appendEpoch(buf []byte, depth int) ([]byte, error) {
+	value, err := d.appendItem(nil, depth)
+	if err != nil {
+		return buf, err
+	}
+	seconds, err := strconv.ParseFloat(string(value), 64)
+	if err != nil {
+		return append(buf, value...), nil
+	}
+
+	whole, fraction := math.Modf(seconds)
+	t := time.Unix(int64(whole), int64(fraction*1e9)).UTC()
+	buf = append(buf, '"')
+	buf = t.AppendFormat(buf, time.RFC3339Nano)
+	return append(buf, '"'), nil
+}
+
+// appendSimple writes simple values and floats
+func (d *cborDecoder) appendSimple(buf []byte, initial byte) ([]byte, error) {
+	switch initial {
+	case cborFalse:
+		return append(buf, "false"...), nil
+	case cborTrue:
+		return append(buf, "true"...), nil
+	case cborNull, cborUndefined:
+		return append(buf, "null"...), nil
+	case cborFloat16:
+		bits, err := d.argument(25)
+		if err != nil {
+			return buf, err
+		}
+		return appendJSONFloat(buf, float16ToFloat64(uint16(bits)), 32), nil
+	case cborFloat32:
+		bits, err := d.argument(26)
+		if err != nil {
+			return buf, err
+		}
+		return appendJSONFloat(buf, float64(math.Float32frombits(uint32(bits))), 32), nil
+	case cborFloat64:
+		bits, err := d.argument(27)
+		if err != nil {
+			return buf, err
+		}
+		return appendJSONFloat(buf, math.Float64frombits(bits), 64), nil
+	case cborBreak:
+		return buf, errors.New("emit: unexpected CBOR break")
+	default:
+		return buf, fmt.Errorf("emit: unsupported CBOR simple value 0x%02x", initial)
+	}
+}
+
+// argument reads the argument following an initial byte
+func (d *cborDecoder) argument(info byte) (uint64, error) {
+	var size int
+	switch {
+	case info < 24:
+		return uint64(info), nil
+	case info == 24:
+		size = 1
+	case info == 25:
+		size = 2
+	case info == 26:
+		size = 4
+	case info == 27:
+		size = 8
+	default:
+		return 0, fmt.Errorf("emit: invalid CBOR additional information %d", info)
+	}
+
+	var scratch [8]byte
+	if _, err := io.ReadFull(d.r, scratch[8-size:]); err != nil {
+		return 0, d.unexpected(err)
+	}
+	return binary.BigEndian.Uint64(scratch[:]), nil
+}
+
+// readString reads a byte or text string, joining indefinite-length chunks
+func (d *cborDecoder) readString(major byte, n uint64, indefinite bool) ([]byte, error) {
+	if !indefinite {
+		if n > cborMaxLength {
+			return nil, fmt.Errorf("emit: CBOR string of %d bytes is too long", n)
+		}
+		data := make([]byte, n)
+		if _, err := io.ReadFull(d.r, data); err != nil {
+			return nil, d.unexpected(err)
+		}
+		return data, nil
+	}
+
+	var data []byte
+	for !d.atBreak() {
+		initial, err := d.r.ReadByte()
+		if err != nil {
+			return nil, d.unexpected(err)
+		}
+		if initial&0xe0 != major || initial&0x1f == cborIndefinite {
+			return nil, fmt.Errorf("emit: invalid CBOR string chunk 0x%02x", initial)
+		}
+		size, err := d.argument(initial & 0x1f)
+		if err != nil {
+			return nil, err
+		}
+		chunk, err := d.readString(major, size, false)
+		if err != nil {
+			return nil, err
+		}
+		if data = append(data, chunk...); len(data) > cborMaxLength {
+			return nil, errors.New("emit: CBOR string is too long")
+		}
+	}
+	return data, nil
+}
+
+// atBreak consumes the break ending an indefinite-length item, if next
+func (d *cborDecoder) atBreak() bool {
+	next, err := d.r.Peek(1)
+	if err != nil || next[0] != cborBreak {
+		return false
+	}
+	_, _ = d.r.ReadByte()
+	return true
+}
+
+// unexpected reports input that ends inside an item
+func (d *cborDecoder) unexpected(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// appendJSONFloat writes a float, quoting NaN and infinities like jsonEncoder
+func appendJSONFloat(buf []byte, value float64, bitSize int) []byte {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return appendJSONString(buf, strconv.FormatFloat(value, 'f', -1, 64))
+	}
+	return strconv.AppendFloat(buf, value, 'f', -1, bitSize)
+}
+
+// float16ToFloat64 widens an IEEE 754 half-precision float
+func float16ToFloat64(bits uint16) float64 {
+	exponent := int(bits>>10) & 0x1f
+	mantissa := float64(bits & 0x3ff)
+
+	var value float64
+	switch exponent {
+	case 0:
+		value = math.Ldexp(mantissa, -24)
+	case 0x1f:
+		if mantissa == 0 {
+			value = math.Inf(1)
+		} else {
+			value = math.NaN()
+		}
+	default:
+		value = math.Ldexp(mantissa+1024, exponent-25)
+	}
+
+	if bits&0x8000 != 0 {
+		return -value
+	}
+	return value
+}
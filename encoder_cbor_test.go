@@ -0,0 +1,139 @@
+package emit
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestCBORFormat tests CBOR entries by converting them back to JSON
+func TestCBORFormat(t *testing.T) {
+	var buf bytes.Buffer
+	testLogger := newTestLogger(&buf, withComponent("checkout", "2.1.0"))
+	testLogger.format = CBOR_FORMAT
+
+	at := time.Date(2024, 5, 1, 12, 0, 0, 500, time.UTC)
+	testLogger.log(INFO, "started", nil)
+	testLogger.log(WARN, "map", map[string]any{
+		"password": "x",
+		"ratio":    0.1,
+		"count":    -3,
+		"request":  map[string]any{"path": "/cart", "tags": []any{"a", int8(1), nil}},
+	})
+	testLogger.logStructuredFields(ERROR, "structured",
+		ZString("email", "a@b.c"), ZBool("ok", true), ZDuration("took", time.Millisecond), ZTime("at", at), ZFloat64("half", 0.5))
+
+	var out bytes.Buffer
+	if err := CBORToJSON(&out, &buf); err != nil {
+		t.Fatalf("CBORToJSON failed: %v", err)
+	}
+	entries := decodeLines(t, out.String())
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 entries, got %d: %s", len(entries), out.String())
+	}
+
+	for _, entry := range entries {
+		if entry["timestamp"] == nil || entry["component"] != "checkout" || entry["version"] != "2.1.0" {
+			t.Errorf("Missing built-in keys: %v", entry)
+		}
+	}
+	if entries[0]["level"] != "info" || entries[0]["message"] != "started" {
+		t.Errorf("Unexpected simple entry: %v", entries[0])
+	}
+
+	mapEntry := entries[1]
+	request, _ := mapEntry["request"].(map[string]any)
+	if mapEntry["password"] != "***MASKED***" || mapEntry["ratio"] != 0.1 || mapEntry["count"] != float64(-3) || request["path"] != "/cart" {
+		t.Errorf("Unexpected map entry: %v", mapEntry)
+	}
+	if tags, _ := request["tags"].([]any); len(tags) != 3 || tags[1] != float64(1) || tags[2] != nil {
+		t.Errorf("Unexpected array field: %v", request["tags"])
+	}
+
+	structured := entries[2]
+	if structured["email"] != "***MASKED***" || structured["ok"] != true || structured["took"] != float64(time.Millisecond) ||
+		structured["at"] != at.Format(time.RFC3339Nano) || structured["half"] != 0.5 {
+		t.Errorf("Unexpected structured entry: %v", structured)
+	}
+}
+
+// TestCBORSmallerThanJSON tests that CBOR entries are more compact
+func TestCBORSmallerThanJSON(t *testing.T) {
+	sizes := map[OutputFormat]int{}
+	for _, format := range []OutputFormat{JSON_FORMAT, CBOR_FORMAT} {
+		var buf bytes.Buffer
+		testLogger := newTestLogger(&buf, withComponent("checkout", "2.1.0"))
+		testLogger.format = format
+		testLogger.logStructuredFields(INFO, "request", ZString("method", "GET"), ZInt("status", 200), ZDuration("took", 1500*time.Microsecond))
+		sizes[format] = buf.Len()
+	}
+	if sizes[CBOR_FORMAT] >= sizes[JSON_FORMAT] {
+		t.Errorf("Expected CBOR (%d bytes) to be smaller than JSON (%d bytes)", sizes[CBOR_FORMAT], sizes[JSON_FORMAT])
+	}
+}
+
+// TestCBORStructuredNoAllocation tests that the CBOR structured path is allocation free
+func TestCBORStructuredNoAllocation(t *testing.T) {
+	testLogger := newTestLogger(io.Discard, withLevel(INFO), withFormat(CBOR_FORMAT))
+
+	fields := []ZField{ZString("k", "v"), ZInt("n", 1), ZFloat64("f", 0.25)}
+	allocs := testing.AllocsPerRun(100, func() {
+		testLogger.logStructuredFields(INFO, "hot path", fields...)
+	})
+	if allocs != 0 {
+		t.Errorf("Expected 0 allocations, got %v", allocs)
+	}
+}
+
+// TestCBORToJSON tests decoding items the encoder does not write itself
+func TestCBORToJSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []byte
+		want  string
+	}{
+		{"half float", []byte{0xa1, 0x61, 'h', 0xf9, 0x3e, 0x00}, `{"h":1.5}`},
+		{"indefinite text", []byte{0x7f, 0x62, 'a', 'b', 0x61, 'c', 0xff}, `"abc"`},
+		{"byte string", []byte{0x42, 0x01, 0x02}, `"AQI="`},
+		{"large negative", []byte{0x3b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, `-18446744073709551616`},
+		{"epoch tag", []byte{0xc1, 0x1a, 0x51, 0x4b, 0x67, 0xb0}, `"2013-03-21T20:04:00Z"`},
+		{"integer key", []byte{0xa1, 0x01, 0xf5}, `{"1":true}`},
+		{"undefined", []byte{0x9f, 0xf7, 0xff}, `[null]`},
+		{"infinity", []byte{0xf9, 0x7c, 0x00}, `"+Inf"`},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		if err := CBORToJSON(&out, bytes.NewReader(tt.input)); err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+			continue
+		}
+		if got := strings.TrimSpace(out.String()); got != tt.want {
+			t.Errorf("%s: expected %s, got %s", tt.name, tt.want, got)
+		}
+	}
+}
+
+// TestCBORToJSONInvalid tests that malformed input is reported
+func TestCBORToJSONInvalid(t *testing.T) {
+	inputs := map[string][]byte{
+		"truncated map":   {0xbf, 0x61, 'k'},
+		"truncated bytes": {0x5a, 0xff, 0xff, 0xff, 0xff},
+		"stray break":     {0xff},
+		"reserved info":   {0x1c},
+		"too deep":        bytes.Repeat([]byte{0x81}, cborMaxDepth+2),
+	}
+
+	for name, input := range inputs {
+		err := CBORToJSON(io.Discard, bytes.NewReader(input))
+		if err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+		if name == "truncated map" && !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("%s: expected io.ErrUnexpectedEOF, got %v", name, err)
+		}
+	}
+}
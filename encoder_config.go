@@ -0,0 +1,157 @@
+package emit
+
+// LevelEncoding selects how an EncoderConfig writes levels
+type LevelEncoding int
+
+const (
+	LEVEL_LOWER   LevelEncoding = iota // "info" (default)
+	LEVEL_UPPER                        // "INFO"
+	LEVEL_NUMERIC                      // The LogLevel value: 0 (debug) to 3 (error)
+)
+
+// CollisionPolicy selects what happens to a flat user field whose key
+// shadows one of the built-in keys
+type CollisionPolicy int
+
+const (
+	COLLISION_PREFIX CollisionPolicy = iota // Write it under CollisionPrefix+key (default)
+	COLLISION_DROP                          // Leave the user field out
+	COLLISION_KEEP                          // Write the duplicate key anyway
+)
+
+// fieldLayout selects where an entry's user fields are written
+type fieldLayout int
+
+const (
+	fieldsByAPI  fieldLayout = iota // Map fields nested, structured fields flat (as logJSON)
+	fieldsFlat                      // All user fields at the top level
+	fieldsNested                    // All user fields in one object
+)
+
+// EncoderConfig controls the keys and layout of JSON entries. Empty keys
+// take their default; it applies to every logging API alike, so map and
+// structured fields produce the same shape.
+type EncoderConfig struct {
+	TimeKey      string // default "timestamp"
+	LevelKey     string // default "level"
+	MessageKey   string // default "message"
+	ComponentKey string // default "component"
+	VersionKey   string // default "version"
+	CallerKey    string // Caller as "file:line" (default "caller")
+	FunctionKey  string // Caller function, written only when set
+
+	LevelEncoding LevelEncoding
+
+	// NestFields writes user fields in an object under FieldsKey
+	// (default "fields") instead of at the top level
+	NestFields bool
+	FieldsKey  string
+
+	// Collision applies to flat fields shadowing a built-in key; prefixed
+	// fields use CollisionPrefix (default FieldsKey + ".")
+	Collision       CollisionPolicy
+	CollisionPrefix string
+}
+
+// DefaultEncoderConfig returns the configuration matching emit's keys,
+// with user fields at the top level
+func DefaultEncoderConfig() EncoderConfig {
+	return EncoderConfig{
+		TimeKey:      logEntryKeys.time,
+		LevelKey:     logEntryKeys.level,
+		MessageKey:   logEntryKeys.message,
+		ComponentKey: logEntryKeys.component,
+		VersionKey:   logEntryKeys.version,
+		CallerKey:    logEntryKeys.caller,
+		FieldsKey:    logEntryKeys.fields,
+	}
+}
+
+// withDefaults fills the empty keys of a configuration
+func (c EncoderConfig) withDefaults() EncoderConfig {
+	defaults := DefaultEncoderConfig()
+	for _, key := range []struct{ value, fallback *string }{
+		{&c.TimeKey, &defaults.TimeKey},
+		{&c.LevelKey, &defaults.LevelKey},
+		{&c.MessageKey, &defaults.MessageKey},
+		{&c.ComponentKey, &defaults.ComponentKey},
+		{&c.VersionKey, &defaults.VersionKey},
+		{&c.CallerKey, &defaults.CallerKey},
+		{&c.FieldsKey, &defaults.FieldsKey},
+	} {
+		if *key.value == "" {
+			*key.value = *key.fallback
+		}
+	}
+	if c.CollisionPrefix == "" {
+		c.CollisionPrefix = c.FieldsKey + "."
+	}
+	return c
+}
+
+// newConfiguredLayout builds the layout of an encoder configuration
+func newConfiguredLayout(config EncoderConfig) *entryLayout {
+	config = config.withDefaults()
+
+	layout := &entryLayout{
+		enc: jsonEncoder{},
+		keys: entryKeys{
+			time:      config.TimeKey,
+			level:     config.LevelKey,
+			message:   config.MessageKey,
+			component: config.ComponentKey,
+			version:   config.VersionKey,
+			caller:    config.CallerKey,
+			function:  config.FunctionKey,
+			fields:    config.FieldsKey,
+		},
+		shape:  shapeLogEntry,
+		levels: config.LevelEncoding,
+		fields: fieldsFlat,
+	}
+	if config.NestFields {
+		layout.fields = fieldsNested
+		return layout
+	}
+
+	reserved := []string{config.TimeKey, config.LevelKey, config.MessageKey, config.ComponentKey, config.VersionKey, config.CallerKey}
+	if config.FunctionKey != "" {
+		reserved = append(reserved, config.FunctionKey)
+	}
+	layout.filter = &fieldFilter{reserved: reserved, collision: config.Collision, prefix: config.CollisionPrefix}
+	return layout
+}
+
+// SetEncoderConfig sets the JSON keys and field layout of the default
+// logger's entries, replacing any profile
+func SetEncoderConfig(config EncoderConfig) {
+	if defaultLogger != nil {
+		defaultLogger.profile = newConfiguredLayout(config)
+	}
+}
+
+// appendLevel appends the level with the layout's level encoding
+func (k *entryLayout) appendLevel(enc entryEncoder, buf []byte, level LogLevel) []byte {
+	switch k.levels {
+	case LEVEL_UPPER:
+		return enc.appendString(buf, k.keys.level, upperLevel(level))
+	case LEVEL_NUMERIC:
+		return enc.appendInt(buf, k.keys.level, int64(level))
+	default:
+		return enc.appendString(buf, k.keys.level, level.StringFast())
+	}
+}
+
+// upperLevel returns the upper case name of a level
+func upperLevel(level LogLevel) string {
+	switch level {
+	case DEBUG:
+		return "DEBUG"
+	case WARN:
+		return "WARN"
+	case ERROR:
+		return "ERROR"
+	default:
+		return "INFO"
+	}
+}
@@ -0,0 +1,119 @@
+package emit
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+// TestEncoderConfigKeys tests renamed keys and level encodings
+func TestEncoderConfigKeys(t *testing.T) {
+	var buf bytes.Buffer
+	testLogger := newTestLogger(&buf, withComponent("checkout", "2.1.0"), withEncoderConfig(EncoderConfig{
+		TimeKey:       "ts",
+		LevelKey:      "severity",
+		MessageKey:    "msg",
+		ComponentKey:  "service",
+		VersionKey:    "release",
+		CallerKey:     "src",
+		FunctionKey:   "func",
+		LevelEncoding: LEVEL_UPPER,
+	}))
+
+	testLogger.showCaller = true
+	testLogger.log(WARN, "disk low", nil)
+
+	entry := decodeLines(t, buf.String())[0]
+	for key, want := range map[string]any{"severity": "WARN", "msg": "disk low", "service": "checkout", "release": "2.1.0"} {
+		if entry[key] != want {
+			t.Errorf("Expected %s=%v, got %v", key, want, entry[key])
+		}
+	}
+	if entry["ts"] == nil || entry["src"] == nil || entry["func"] == nil {
+		t.Errorf("Missing timestamp or caller: %v", entry)
+	}
+	if entry["timestamp"] != nil || entry["level"] != nil || entry["message"] != nil {
+		t.Errorf("Default keys still written: %v", entry)
+	}
+
+	buf.Reset()
+	testLogger.profile = newConfiguredLayout(EncoderConfig{LevelEncoding: LEVEL_NUMERIC})
+	testLogger.log(ERROR, "failed", nil)
+	if level := decodeLines(t, buf.String())[0]["level"]; level != float64(ERROR) {
+		t.Errorf("Expected numeric level %d, got %v", ERROR, level)
+	}
+}
+
+// TestEncoderConfigFieldLayout tests that map and structured fields take
+// the same shape, flat or nested
+func TestEncoderConfigFieldLayout(t *testing.T) {
+	for _, nest := range []bool{false, true} {
+		var buf bytes.Buffer
+		testLogger := newTestLogger(&buf, withComponent("checkout", "2.1.0"), withEncoderConfig(EncoderConfig{NestFields: nest, FieldsKey: "attrs"}))
+
+		testLogger.log(INFO, "map", map[string]any{"order_id": 42, "password": "x"})
+		testLogger.logStructuredFields(INFO, "structured", ZInt("order_id", 42), ZString("password", "x"))
+
+		for _, entry := range decodeLines(t, buf.String()) {
+			fields := entry
+			if nest {
+				fields, _ = entry["attrs"].(map[string]any)
+			}
+			if fields["order_id"] != float64(42) || fields["password"] != "***MASKED***" {
+				t.Errorf("nest=%v: unexpected fields in %v", nest, entry)
+			}
+			if !nest && entry["attrs"] != nil {
+				t.Errorf("Flat entry has a fields object: %v", entry)
+			}
+		}
+	}
+}
+
+// TestEncoderConfigCollisions tests user fields shadowing built-in keys
+func TestEncoderConfigCollisions(t *testing.T) {
+	tests := []struct {
+		collision CollisionPolicy
+		key       string
+		present   bool
+	}{
+		{COLLISION_PREFIX, "fields.message", true},
+		{COLLISION_DROP, "fields.message", false},
+	}
+
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		testLogger := newTestLogger(&buf, withComponent("checkout", "2.1.0"), withEncoderConfig(EncoderConfig{Collision: tt.collision}))
+
+		testLogger.log(INFO, "real", map[string]any{"message": "shadow", "user": "a"})
+		testLogger.logStructuredFields(INFO, "real", ZString("message", "shadow"), ZString("user", "a"))
+
+		for _, entry := range decodeLines(t, buf.String()) {
+			if entry["message"] != "real" || entry["user"] != "a" {
+				t.Errorf("Built-in or plain field lost: %v", entry)
+			}
+			if _, ok := entry[tt.key]; ok != tt.present {
+				t.Errorf("collision=%d: expected %s present=%v in %v", tt.collision, tt.key, tt.present, entry)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	testLogger := newTestLogger(&buf, withComponent("checkout", "2.1.0"), withEncoderConfig(EncoderConfig{Collision: COLLISION_KEEP}))
+	testLogger.logStructuredFields(INFO, "real", ZString("message", "shadow"))
+	if got := bytes.Count(buf.Bytes(), []byte(`"message":`)); got != 2 {
+		t.Errorf("Expected the duplicate key to be kept, got %d in %s", got, buf.String())
+	}
+}
+
+// TestEncoderConfigNoAllocation tests that structured fields stay allocation free
+func TestEncoderConfigNoAllocation(t *testing.T) {
+	testLogger := newTestLogger(io.Discard, withLevel(INFO), withEncoderConfig(EncoderConfig{}))
+
+	fields := []ZField{ZString("method", "GET"), ZInt("status", 200)}
+	allocs := testing.AllocsPerRun(100, func() {
+		testLogger.logStructuredFields(INFO, "request", fields...)
+	})
+	if allocs != 0 {
+		t.Errorf("Expected 0 allocations, got %v", allocs)
+	}
+}
@@ -0,0 +1,171 @@
+package emit
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// objectEncoder is an entryEncoder that can nest objects
+type objectEncoder interface {
+	entryEncoder
+	openObject(buf []byte, key string) []byte
+	closeObject(buf []byte) []byte
+}
+
+// jsonEncoder writes entries as one JSON object per line. It keeps no
+// state: a separator is needed unless the previous byte opened an object.
+type jsonEncoder struct{}
+
+func (jsonEncoder) begin(buf []byte) []byte {
+	return append(buf, '{')
+}
+
+func (jsonEncoder) end(buf []byte) []byte {
+	return append(buf, '}', '\n')
+}
+
+func (jsonEncoder) appendKey(buf []byte, key string) []byte {
+	if len(buf) > 0 && buf[len(buf)-1] != '{' {
+		buf = append(buf, ',')
+	}
+	buf = appendJSONString(buf, key)
+	return append(buf, ':')
+}
+
+func (e jsonEncoder) openObject(buf []byte, key string) []byte {
+	buf = e.appendKey(buf, key)
+	return append(buf, '{')
+}
+
+func (jsonEncoder) closeObject(buf []byte) []byte {
+	return append(buf, '}')
+}
+
+func (e jsonEncoder) appendString(buf []byte, key, value string) []byte {
+	buf = e.appendKey(buf, key)
+	return appendJSONString(buf, value)
+}
+
+func (e jsonEncoder) appendInt(buf []byte, key string, value int64) []byte {
+	buf = e.appendKey(buf, key)
+	return strconv.AppendInt(buf, value, 10)
+}
+
+func (e jsonEncoder) appendFloat(buf []byte, key string, value float64) []byte {
+	buf = e.appendKey(buf, key)
+
+	// JSON has no NaN or infinities
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return appendJSONString(buf, strconv.FormatFloat(value, 'f', -1, 64))
+	}
+	return strconv.AppendFloat(buf, value, 'f', -1, 64)
+}
+
+func (e jsonEncoder) appendBool(buf []byte, key string, value bool) []byte {
+	buf = e.appendKey(buf, key)
+	return strconv.AppendBool(buf, value)
+}
+
+func (e jsonEncoder) appendTime(buf []byte, key string, value time.Time) []byte {
+	buf = e.appendKey(buf, key)
+	buf = append(buf, '"')
+	buf = value.AppendFormat(buf, time.RFC3339Nano)
+	return append(buf, '"')
+}
+
+// appendEntryTime writes epoch timestamps as numbers and others as strings
+func (e jsonEncoder) appendEntryTime(buf []byte, key string, te *timeEncoder, now time.Time) []byte {
+	buf = e.appendKey(buf, key)
+	if te.numeric() {
+		return te.append(buf, now)
+	}
+	if te.escape {
+		return appendJSONString(buf, string(te.append(nil, now)))
+	}
+	buf = append(buf, '"')
+	buf = te.append(buf, now)
+	return append(buf, '"')
+}
+
+// appendDuration writes nanoseconds, like ZDuration and encoding/json
+func (e jsonEncoder) appendDuration(buf []byte, key string, value time.Duration) []byte {
+	buf = e.appendKey(buf, key)
+	return strconv.AppendInt(buf, int64(value), 10)
+}
+
+func (e jsonEncoder) appendAny(buf []byte, key string, value any) []byte {
+	buf = e.appendKey(buf, key)
+	data, err := json.Marshal(value)
+	if err != nil {
+		return appendJSONString(buf, fmt.Sprint(value))
+	}
+	return append(buf, data...)
+}
+
+// appendStack writes an array of {function, file, line} objects
+func (e jsonEncoder) appendStack(buf []byte, key string, frames []stackFrame) []byte {
+	buf = e.appendKey(buf, key)
+	buf = append(buf, '[')
+	for i, frame := range frames {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		buf = append(buf, `{"function":`...)
+		buf = appendJSONString(buf, frame.function)
+		buf = append(buf, `,"file":`...)
+		buf = appendJSONString(buf, frame.file)
+		buf = append(buf, `,"line":`...)
+		buf = strconv.AppendInt(buf, int64(frame.line), 10)
+		buf = append(buf, '}')
+	}
+	return append(buf, ']')
+}
+
+// appendJSONString appends a quoted, escaped JSON string
+func appendJSONString(buf []byte, s string) []byte {
+	const hex = "0123456789abcdef"
+
+	buf = append(buf, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			if c >= ' ' && c != '"' && c != '\\' {
+				i++
+				continue
+			}
+			buf = append(buf, s[start:i]...)
+			switch c {
+			case '"', '\\':
+				buf = append(buf, '\\', c)
+			case '\n':
+				buf = append(buf, '\\', 'n')
+			case '\r':
+				buf = append(buf, '\\', 'r')
+			case '\t':
+				buf = append(buf, '\\', 't')
+			default:
+				buf = append(buf, '\\', 'u', '0', '0', hex[c>>4], hex[c&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			buf = append(buf, s[start:i]...)
+			buf = append(buf, `�`...)
+			i++
+			start = i
+			continue
+		}
+		i += size
+	}
+	buf = append(buf, s[start:]...)
+	return append(buf, '"')
+}
@@ -0,0 +1,195 @@
+package emit
+
+import (
+	"bytes"
+	"os"
+	"runtime"
+	"runtime/debug"
+)
+
+// EnrichmentConfig selects the metadata added to every entry of the
+// structured formats (JSON, logfmt, CBOR and the profiles). Static metadata
+// is read once by SetEnrichment and pre-encoded, so it costs a copy per entry.
+type EnrichmentConfig struct {
+	// Host adds host.name
+	Host bool
+
+	// Process adds process.pid
+	Process bool
+
+	// Kubernetes adds k8s.pod.name, k8s.namespace.name, k8s.node.name and
+	// k8s.container.name from the POD_NAME, POD_NAMESPACE, NODE_NAME and
+	// CONTAINER_NAME variables set through the Downward API (when present)
+	Kubernetes bool
+
+	// Build adds build.version (the main module version) and build.revision
+	// (the VCS revision, with a "-dirty" suffix for modified trees)
+	Build bool
+
+	// Goroutine adds the goroutine ID of each entry, which reads the stack
+	// header of the logging goroutine and is not free
+	Goroutine bool
+}
+
+// DefaultEnrichment adds all static metadata
+var DefaultEnrichment = EnrichmentConfig{Host: true, Process: true, Kubernetes: true, Build: true}
+
+// enrichmentField is one static metadata field
+type enrichmentField struct {
+	key    string
+	value  string
+	number int64
+	isInt  bool
+}
+
+// enrichment is the static metadata of a logger, pre-encoded per encoder
+type enrichment struct {
+	fields    []enrichmentField
+	json      []byte // With a leading separator
+	logfmt    []byte // With a leading separator
+	cbor      []byte
+	goroutine bool
+}
+
+// kubernetesEnvironment maps Downward API variables to their keys
+var kubernetesEnvironment = [...]struct{ variable, key string }{
+	{"POD_NAME", "k8s.pod.name"},
+	{"POD_NAMESPACE", "k8s.namespace.name"},
+	{"NODE_NAME", "k8s.node.name"},
+	{"CONTAINER_NAME", "k8s.container.name"},
+}
+
+// SetEnrichment adds metadata to the default logger's entries
+func SetEnrichment(config EnrichmentConfig) {
+	if defaultLogger != nil {
+		defaultLogger.enrichment = newEnrichment(config)
+	}
+}
+
+// DisableEnrichment stops adding metadata to the default logger's entries
+func DisableEnrichment() {
+	if defaultLogger != nil {
+		defaultLogger.enrichment = nil
+	}
+}
+
+// newEnrichment reads the static metadata and pre-encodes it
+func newEnrichment(config EnrichmentConfig) *enrichment {
+	e := &enrichment{goroutine: config.Goroutine}
+
+	if config.Host {
+		if host, err := os.Hostname(); err == nil {
+			e.fields = append(e.fields, enrichmentField{key: "host.name", value: host})
+		}
+	}
+	if config.Process {
+		e.fields = append(e.fields, enrichmentField{key: "process.pid", number: int64(os.Getpid()), isInt: true})
+	}
+	if config.Kubernetes {
+		for _, env := range kubernetesEnvironment {
+			if value := os.Getenv(env.variable); value != "" {
+				e.fields = append(e.fields, enrichmentField{key: env.key, value: value})
+			}
+		}
+	}
+	if config.Build {
+		e.fields = append(e.fields, buildFields()...)
+	}
+
+	// Encode after a placeholder byte so the separators are included
+	e.json = e.appendFields(jsonEncoder{}, []byte{'x'})[1:]
+	e.logfmt = e.appendFields(logfmtEncoder{}, []byte{'x'})[1:]
+	e.cbor = e.appendFields(cborEncoder{}, nil)
+	return e
+}
+
+// buildFields returns the main module version and VCS revision
+func buildFields() []enrichmentField {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return nil
+	}
+
+	var fields []enrichmentField
+	if version := info.Main.Version; version != "" && version != "(devel)" {
+		fields = append(fields, enrichmentField{key: "build.version", value: version})
+	}
+
+	var revision string
+	var modified bool
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			revision = setting.Value
+		case "vcs.modified":
+			modified = setting.Value == "true"
+		}
+	}
+	if revision != "" {
+		if modified {
+			revision += "-dirty"
+		}
+		fields = append(fields, enrichmentField{key: "build.revision", value: revision})
+	}
+	return fields
+}
+
+// appendFields encodes the static fields, unmasked
+func (e *enrichment) appendFields(enc entryEncoder, buf []byte) []byte {
+	for _, field := range e.fields {
+		if field.isInt {
+			buf = enc.appendInt(buf, field.key, field.number)
+		} else {
+			buf = enc.appendString(buf, field.key, field.value)
+		}
+	}
+	return buf
+}
+
+// appendEnrichment appends the logger's metadata, after the built-in keys
+func (l *Logger) appendEnrichment(enc entryEncoder, buf []byte) []byte {
+	e := l.enrichment
+	if e == nil {
+		return buf
+	}
+
+	switch enc.(type) {
+	case jsonEncoder:
+		buf = append(buf, e.json...)
+	case logfmtEncoder:
+		buf = append(buf, e.logfmt...)
+	case cborEncoder:
+		buf = append(buf, e.cbor...)
+	default:
+		buf = e.appendFields(enc, buf)
+	}
+
+	if e.goroutine {
+		buf = enc.appendInt(buf, "goroutine", goroutineID())
+	}
+	return buf
+}
+
+// enrichedJSON returns the pre-encoded metadata of the hand-built JSON paths
+func (l *Logger) enrichedJSON() []byte {
+	if l.enrichment == nil {
+		return nil
+	}
+	return l.enrichment.json
+}
+
+// goroutineID parses the ID from the "goroutine 42 [running]:" stack header
+func goroutineID() int64 {
+	var header [64]byte
+	line := header[:runtime.Stack(header[:], false)]
+	line = bytes.TrimPrefix(line, []byte("goroutine "))
+
+	var id int64
+	for _, c := range line {
+		if c < '0' || c > '9' {
+			break
+		}
+		id = id*10 + int64(c-'0')
+	}
+	return id
+}
@@ -0,0 +1,150 @@
+package emit
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// newEnrichTestLogger returns a logger adding host, process and Kubernetes metadata
+func newEnrichTestLogger(t *testing.T, buf io.Writer, format OutputFormat) *Logger {
+	t.Setenv("POD_NAME", "api-7d9f")
+	t.Setenv("POD_NAMESPACE", "prod")
+	t.Setenv("NODE_NAME", "")
+	t.Setenv("CONTAINER_NAME", "")
+	config := EnrichmentConfig{Host: true, Process: true, Kubernetes: true}
+	return newTestLogger(buf, withFormat(format), withComponent("api", ""), withEnrichment(config))
+}
+
+// checkEnrichedEntry tests the metadata keys of a decoded entry
+func checkEnrichedEntry(t *testing.T, line string, entry map[string]any) {
+	t.Helper()
+	host, _ := os.Hostname()
+	if entry["host.name"] != host {
+		t.Errorf("Expected host.name %q in %s", host, line)
+	}
+	if pid, _ := entry["process.pid"].(float64); int(pid) != os.Getpid() {
+		t.Errorf("Expected process.pid %d in %s", os.Getpid(), line)
+	}
+	if entry["k8s.pod.name"] != "api-7d9f" || entry["k8s.namespace.name"] != "prod" {
+		t.Errorf("Expected the pod name and namespace in %s", line)
+	}
+	if _, ok := entry["k8s.node.name"]; ok {
+		t.Errorf("Expected no k8s.node.name without NODE_NAME in %s", line)
+	}
+}
+
+// TestEnrichmentJSON tests the metadata on every JSON path
+func TestEnrichmentJSON(t *testing.T) {
+	var buf bytes.Buffer
+	testLogger := newEnrichTestLogger(t, &buf, JSON_FORMAT)
+
+	testLogger.log(INFO, "simple", nil)
+	testLogger.log(INFO, "map", map[string]any{"k": "v"})
+	testLogger.logStructuredFields(INFO, "structured", ZString("k", "v"))
+	testLogger.logStructuredFieldsDynamic(INFO, "dynamic", ZString("k", "v"))
+	testLogger.profile = ecsLayout
+	testLogger.log(INFO, "profile", nil)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("Expected 5 entries, got %q", buf.String())
+	}
+	for _, line := range lines {
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("Invalid JSON %q: %v", line, err)
+		}
+		checkEnrichedEntry(t, line, entry)
+	}
+}
+
+// TestEnrichmentLogfmtAndCBOR tests the metadata in the other structured formats
+func TestEnrichmentLogfmtAndCBOR(t *testing.T) {
+	var buf bytes.Buffer
+	newEnrichTestLogger(t, &buf, LOGFMT_FORMAT).logStructuredFields(INFO, "m", ZString("k", "v"))
+	want := " process.pid=" + strconv.Itoa(os.Getpid()) + " k8s.pod.name=api-7d9f k8s.namespace.name=prod\n"
+	if !strings.HasSuffix(buf.String(), want) {
+		t.Errorf("Expected logfmt ending in %q, got %q", want, buf.String())
+	}
+
+	buf.Reset()
+	newEnrichTestLogger(t, &buf, CBOR_FORMAT).logStructuredFields(INFO, "m", ZString("k", "v"))
+	var out bytes.Buffer
+	if err := CBORToJSON(&out, &buf); err != nil {
+		t.Fatalf("CBORToJSON failed: %v", err)
+	}
+	var entry map[string]any
+	if err := json.Unmarshal(out.Bytes(), &entry); err != nil {
+		t.Fatalf("Invalid JSON %q: %v", out.String(), err)
+	}
+	checkEnrichedEntry(t, out.String(), entry)
+}
+
+// TestEnrichmentGoroutine tests that each entry carries its goroutine's ID
+func TestEnrichmentGoroutine(t *testing.T) {
+	var buf bytes.Buffer
+	testLogger := newTestLogger(&buf, withEnrichment(EnrichmentConfig{Goroutine: true}))
+
+	testLogger.logStructuredFields(INFO, "structured", ZString("k", "v"))
+	testLogger.log(INFO, "simple", nil)
+	done := make(chan int64)
+	go func() {
+		testLogger.log(INFO, "other goroutine", map[string]any{"k": "v"})
+		done <- goroutineID()
+	}()
+	other := <-done
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 entries, got %q", buf.String())
+	}
+	for i, want := range []int64{goroutineID(), goroutineID(), other} {
+		var entry struct {
+			Goroutine int64 `json:"goroutine"`
+		}
+		if err := json.Unmarshal([]byte(lines[i]), &entry); err != nil {
+			t.Fatalf("Invalid JSON %q: %v", lines[i], err)
+		}
+		if entry.Goroutine != want || want == 0 {
+			t.Errorf("Expected goroutine %d, got %d in %s", want, entry.Goroutine, lines[i])
+		}
+	}
+	if other == goroutineID() {
+		t.Errorf("Expected distinct IDs for distinct goroutines, got %d twice", other)
+	}
+}
+
+// TestEnrichmentSetAndDisable tests the package-level configuration
+func TestEnrichmentSetAndDisable(t *testing.T) {
+	var buf bytes.Buffer
+	useTestLogger(t, &buf)
+
+	SetEnrichment(EnrichmentConfig{Process: true})
+	Info.Msg("enriched")
+	DisableEnrichment()
+	Info.Msg("plain")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	pid := `"process.pid":` + strconv.Itoa(os.Getpid())
+	if len(lines) != 2 || !strings.Contains(lines[0], pid) || strings.Contains(lines[1], "process.pid") {
+		t.Errorf("Expected process.pid only in the first entry, got %q", buf.String())
+	}
+}
+
+// TestEnrichmentNoAllocation tests that static metadata keeps the structured path allocation free
+func TestEnrichmentNoAllocation(t *testing.T) {
+	testLogger := newEnrichTestLogger(t, io.Discard, JSON_FORMAT)
+
+	fields := []ZField{ZString("k", "v"), ZInt("n", 1)}
+	allocs := testing.AllocsPerRun(100, func() {
+		testLogger.logStructuredFields(INFO, "hot path", fields...)
+	})
+	if allocs != 0 {
+		t.Errorf("Expected 0 allocations, got %v", allocs)
+	}
+}
@@ -0,0 +1,418 @@
+package emit
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+)
+
+// ANSI escape codes used by the console format
+const (
+	ansiReset  = "\033[0m"
+	ansiDim    = "\033[2m"
+	ansiRed    = "\033[31m"
+	ansiGreen  = "\033[32m"
+	ansiYellow = "\033[33m"
+	ansiBlue   = "\033[34m"
+	ansiCyan   = "\033[36m"
+)
+
+// ColorMode selects when the console format writes ANSI colors
+type ColorMode int
+
+const (
+	COLOR_AUTO   ColorMode = iota // Colors on terminals, unless NO_COLOR is set (default)
+	COLOR_ALWAYS                  // Always write colors
+	COLOR_NEVER                   // Never write colors
+)
+
+// ConsoleConfig configures the developer console format. Each entry is
+// one line - time, level, component, caller, message then the fields in
+// key order - followed by any error chains and multiline values (such as
+// stack traces) rendered below it.
+type ConsoleConfig struct {
+	// TimeFormat lays out the local time (default "15:04:05.000")
+	TimeFormat string
+
+	// RelativeTime shows the time elapsed since the format was selected
+	// instead of the clock time
+	RelativeTime bool
+
+	Color ColorMode
+
+	// MessageWidth pads messages so fields line up (default 40, negative disables)
+	MessageWidth int
+}
+
+// consoleOptions holds the settings of a console layout
+type consoleOptions struct {
+	timeFormat string
+	relative   bool
+	started    time.Time
+	color      ColorMode
+	noColor    bool // NO_COLOR was non-empty when the layout was built
+	width      int
+
+	terminal atomic.Pointer[terminalState]
+}
+
+// terminalState caches whether an output file is a terminal
+type terminalState struct {
+	file     *os.File
+	terminal bool
+}
+
+// defaultConsoleLayout is used by CONSOLE_FORMAT unless configured
+var defaultConsoleLayout = newConsoleLayout(ConsoleConfig{})
+
+// newConsoleLayout builds a console layout
+func newConsoleLayout(config ConsoleConfig) *entryLayout {
+	if config.TimeFormat == "" {
+		config.TimeFormat = "15:04:05.000"
+	}
+	if config.MessageWidth == 0 {
+		config.MessageWidth = 40
+	}
+
+	return &entryLayout{
+		shape: shapeConsole,
+		console: &consoleOptions{
+			timeFormat: config.TimeFormat,
+			relative:   config.RelativeTime,
+			started:    time.Now(),
+			color:      config.Color,
+			noColor:    os.Getenv("NO_COLOR") != "",
+			width:      config.MessageWidth,
+		},
+	}
+}
+
+// SetConsoleFormat switches the default logger to the developer console format
+func SetConsoleFormat(config ConsoleConfig) {
+	if defaultLogger != nil {
+		layout := newConsoleLayout(config)
+		layout.console.started = defaultLogger.now()
+		defaultLogger.console = layout
+		defaultLogger.format = CONSOLE_FORMAT
+	}
+}
+
+// consoleLayout returns the logger's console layout
+func (l *Logger) consoleLayout() *entryLayout {
+	if l.console != nil {
+		return l.console
+	}
+	return defaultConsoleLayout
+}
+
+// useColor reports whether entries written to w are colored
+func (o *consoleOptions) useColor(w io.Writer) bool {
+	switch o.color {
+	case COLOR_ALWAYS:
+		return true
+	case COLOR_NEVER:
+		return false
+	}
+	if o.noColor || runtime.GOOS == "windows" {
+		return false
+	}
+
+	file, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	if state := o.terminal.Load(); state != nil && state.file == file {
+		return state.terminal
+	}
+
+	info, err := file.Stat()
+	terminal := err == nil && info.Mode()&os.ModeCharDevice != 0
+	o.terminal.Store(&terminalState{file: file, terminal: terminal})
+	return terminal
+}
+
+// appendConsole appends a console entry
+func (k *entryLayout) appendConsole(l *Logger, buf []byte, level LogLevel, message string, fields map[string]any, zfields []ZField) ([]byte, int) {
+	o := k.console
+	color := o.useColor(l.writer)
+	enc := consoleEncoder{color: color}
+
+	if o.relative {
+		elapsed := l.now().Sub(o.started)
+		buf = appendColored(buf, color, ansiDim, "+"+strconv.FormatFloat(elapsed.Seconds(), 'f', 3, 64)+"s")
+	} else {
+		buf = appendColored(buf, color, ansiDim, l.now().Format(o.timeFormat))
+	}
+	buf = append(buf, ' ')
+	buf = appendColored(buf, color, consoleLevelColor(level), consoleLevel(level))
+
+	if l.component != "" || l.version != "" {
+		buf = append(buf, " ["...)
+		buf = append(buf, strings.TrimSpace(l.component+" "+l.version)...)
+		buf = append(buf, ']')
+	}
+	if l.showCaller {
+		if frame, ok := l.caller(); ok {
+			buf = append(buf, ' ')
+			buf = appendColored(buf, color, ansiDim, l.trimmedCallerLine(frame))
+		}
+	}
+	buf = append(buf, ' ')
+	buf = append(buf, message...)
+
+	// Errors and multiline values are rendered below the entry line
+	var below []string
+	masked := 0
+	inline := len(zfields)
+	if zfields != nil {
+		for _, field := range zfields {
+			if f, ok := field.(StringZField); ok && strings.Contains(f.Value, "\n") && !f.IsSensitive() && !f.IsPII() {
+				below = append(below, f.Key)
+			}
+		}
+	} else if len(fields) > 0 {
+		if l.metrics != nil {
+			masked = l.countMaskedFields(fields)
+		}
+		fields = l.maskSensitiveFieldsFast(fields)
+		for key, value := range fields {
+			if consoleMultiline(value) {
+				below = append(below, key)
+			}
+		}
+		slices.Sort(below)
+		inline = len(fields)
+	}
+	inline -= len(below)
+
+	if inline > 0 {
+		for pad := o.width - utf8.RuneCountInString(message); pad > 0; pad-- {
+			buf = append(buf, ' ')
+		}
+		var filter *fieldFilter
+		if len(below) > 0 {
+			filter = &fieldFilter{skip: below}
+		}
+		if zfields != nil {
+			buf, masked = appendZFields(enc, buf, zfields, filter)
+		} else {
+			buf = appendFields(enc, buf, "", fields, filter)
+		}
+	}
+	buf = append(buf, '\n')
+
+	for _, key := range below {
+		if zfields != nil {
+			value, _ := stringField(nil, zfields, key)
+			buf = appendConsoleLines(buf, color, key, value)
+		} else if err, ok := fields[key].(error); ok {
+			buf = appendConsoleError(buf, color, key, err)
+		} else {
+			buf = appendConsoleLines(buf, color, key, fmt.Sprint(fields[key]))
+		}
+	}
+
+	return l.appendStackText(buf, level), masked
+}
+
+// consoleMultiline reports whether a map field is rendered below the entry:
+// errors with a cause or detail, and strings spanning several lines
+func consoleMultiline(value any) bool {
+	switch v := value.(type) {
+	case error:
+		return len(consoleErrorLines(v)) > 1
+	case string:
+		return strings.Contains(v, "\n")
+	default:
+		return false
+	}
+}
+
+// appendConsoleLines appends a multiline value indented under its key
+func appendConsoleLines(buf []byte, color bool, key, value string) []byte {
+	buf = append(buf, "  "...)
+	buf = appendColored(buf, color, ansiCyan, key)
+	buf = append(buf, ":\n"...)
+	for _, line := range strings.Split(strings.TrimRight(value, "\n"), "\n") {
+		buf = append(buf, "    "...)
+		buf = append(buf, line...)
+		buf = append(buf, '\n')
+	}
+	return buf
+}
+
+// appendConsoleError appends an error with its chain of causes
+func appendConsoleError(buf []byte, color bool, key string, err error) []byte {
+	lines := consoleErrorLines(err)
+	buf = append(buf, "  "...)
+	buf = appendColored(buf, color, ansiRed, key)
+	buf = append(buf, ": "...)
+	buf = append(buf, lines[0]...)
+	buf = append(buf, '\n')
+	for _, line := range lines[1:] {
+		buf = append(buf, "    "...)
+		buf = append(buf, line...)
+		buf = append(buf, '\n')
+	}
+	return buf
+}
+
+// consoleErrorLines renders an error as its message followed by each
+// wrapped cause, or by its detailed %+v form (such as a stack trace) when
+// it has one
+func consoleErrorLines(err error) []string {
+	if _, ok := err.(fmt.Formatter); ok {
+		if detailed := fmt.Sprintf("%+v", err); detailed != err.Error() && strings.Contains(detailed, "\n") {
+			return strings.Split(strings.TrimRight(detailed, "\n"), "\n")
+		}
+	}
+
+	lines := []string{err.Error()}
+	var walk func(err error, indent string)
+	walk = func(err error, indent string) {
+		switch wrapped := err.(type) {
+		case interface{ Unwrap() error }:
+			if cause := wrapped.Unwrap(); cause != nil {
+				lines = append(lines, fmt.Sprintf("%scaused by: %s (%T)", indent, cause.Error(), cause))
+				walk(cause, indent)
+			}
+		case interface{ Unwrap() []error }:
+			for _, cause := range wrapped.Unwrap() {
+				lines = append(lines, fmt.Sprintf("%s- %s (%T)", indent, cause.Error(), cause))
+				walk(cause, indent+"  ")
+			}
+		}
+	}
+	walk(err, "")
+	return lines
+}
+
+// shortCaller trims a file path to its directory and name
+func shortCaller(file string) string {
+	dir, name := filepath.Split(file)
+	if dir == "" {
+		return name
+	}
+	return filepath.Join(filepath.Base(dir), name)
+}
+
+// consoleLevel returns the upper case level padded for alignment
+func consoleLevel(level LogLevel) string {
+	switch level {
+	case DEBUG:
+		return "DEBUG"
+	case WARN:
+		return "WARN "
+	case ERROR:
+		return "ERROR"
+	default:
+		return "INFO "
+	}
+}
+
+// consoleLevelColor returns the ANSI color of a level
+func consoleLevelColor(level LogLevel) string {
+	switch level {
+	case DEBUG:
+		return ansiBlue
+	case WARN:
+		return ansiYellow
+	case ERROR:
+		return ansiRed
+	default:
+		return ansiGreen
+	}
+}
+
+// appendColored appends text wrapped in a color if colors are enabled
+func appendColored(buf []byte, color bool, code, text string) []byte {
+	if !color {
+		return append(buf, text...)
+	}
+	buf = append(buf, code...)
+	buf = append(buf, text...)
+	return append(buf, ansiReset...)
+}
+
+// consoleEncoder writes fields as key=value pairs with colored keys,
+// quoting values like logfmt only when needed
+type consoleEncoder struct {
+	color bool
+}
+
+func (consoleEncoder) begin(buf []byte) []byte {
+	return buf
+}
+
+func (consoleEncoder) end(buf []byte) []byte {
+	return append(buf, '\n')
+}
+
+func (e consoleEncoder) appendKey(buf []byte, key string) []byte {
+	buf = append(buf, ' ')
+	buf = appendColored(buf, e.color, ansiCyan, key)
+	return append(buf, '=')
+}
+
+func (e consoleEncoder) appendString(buf []byte, key, value string) []byte {
+	buf = e.appendKey(buf, key)
+	return appendLogfmtValue(buf, value)
+}
+
+func (e consoleEncoder) appendInt(buf []byte, key string, value int64) []byte {
+	buf = e.appendKey(buf, key)
+	return strconv.AppendInt(buf, value, 10)
+}
+
+func (e consoleEncoder) appendFloat(buf []byte, key string, value float64) []byte {
+	buf = e.appendKey(buf, key)
+	return strconv.AppendFloat(buf, value, 'f', -1, 64)
+}
+
+func (e consoleEncoder) appendBool(buf []byte, key string, value bool) []byte {
+	buf = e.appendKey(buf, key)
+	return strconv.AppendBool(buf, value)
+}
+
+// appendTime writes times in the local zone
+func (e consoleEncoder) appendTime(buf []byte, key string, value time.Time) []byte {
+	buf = e.appendKey(buf, key)
+	return value.Local().AppendFormat(buf, time.RFC3339Nano)
+}
+
+func (e consoleEncoder) appendEntryTime(buf []byte, key string, te *timeEncoder, now time.Time) []byte {
+	buf = e.appendKey(buf, key)
+	if te.quote {
+		return appendLogfmtValue(buf, string(te.append(nil, now)))
+	}
+	return te.append(buf, now)
+}
+
+func (e consoleEncoder) appendDuration(buf []byte, key string, value time.Duration) []byte {
+	buf = e.appendKey(buf, key)
+	return append(buf, value.String()...)
+}
+
+// appendStack writes the frames as one quoted value; appendConsole renders
+// stack traces below the entry instead
+func (e consoleEncoder) appendStack(buf []byte, key string, frames []stackFrame) []byte {
+	buf = e.appendKey(buf, key)
+	var scratch [1024]byte
+	return appendLogfmtValue(buf, string(appendStackLines(scratch[:0], frames, "")))
+}
+
+func (e consoleEncoder) appendAny(buf []byte, key string, value any) []byte {
+	buf = e.appendKey(buf, key)
+	if value == nil {
+		return append(buf, "null"...)
+	}
+	return appendLogfmtValue(buf, fmt.Sprint(value))
+}
@@ -0,0 +1,119 @@
+package emit
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestConsoleFormat tests the entry line, key order, quoting and masking
+func TestConsoleFormat(t *testing.T) {
+	var buf bytes.Buffer
+	testLogger := newTestLogger(&buf, withComponent("checkout", "2.1.0"), withConsole(ConsoleConfig{MessageWidth: 12}))
+
+	testLogger.log(WARN, "cart", map[string]any{"zeta": 1, "alpha": "two words", "password": "x", "empty": ""})
+	testLogger.logStructuredFields(INFO, "request", ZString("path", "/a"), ZInt("status", 200))
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %q", buf.String())
+	}
+	if strings.Contains(buf.String(), "\033[") {
+		t.Errorf("Expected no colors when not writing to a terminal: %q", buf.String())
+	}
+
+	want := ` WARN  [checkout 2.1.0] cart         alpha="two words" empty="" password=***MASKED*** zeta=1`
+	if !strings.HasSuffix(lines[0], want) {
+		t.Errorf("Expected line ending %q, got %q", want, lines[0])
+	}
+	if !strings.HasSuffix(lines[1], ` INFO  [checkout 2.1.0] request      path=/a status=200`) {
+		t.Errorf("Unexpected structured line %q", lines[1])
+	}
+}
+
+// TestConsoleErrorsAndMultiline tests error chains and multiline values below the entry
+func TestConsoleErrorsAndMultiline(t *testing.T) {
+	var buf bytes.Buffer
+	testLogger := newTestLogger(&buf, withComponent("checkout", "2.1.0"), withConsole(ConsoleConfig{MessageWidth: -1}))
+
+	cause := errors.New("connection reset")
+	testLogger.log(ERROR, "payment failed", map[string]any{
+		"error":    fmt.Errorf("charge card: %w", cause),
+		"stack":    "main.pay()\nmain.main()",
+		"order_id": 42,
+		"plain":    errors.New("no cause"),
+	})
+
+	want := []string{
+		`payment failed order_id=42 plain="no cause"`,
+		"  error: charge card: connection reset",
+		"    caused by: connection reset (*errors.errorString)",
+		"  stack:",
+		"    main.pay()",
+		"    main.main()",
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != len(want) {
+		t.Fatalf("Expected %d lines, got %q", len(want), buf.String())
+	}
+	if !strings.HasSuffix(lines[0], want[0]) {
+		t.Errorf("Expected line ending %q, got %q", want[0], lines[0])
+	}
+	for i := 1; i < len(want); i++ {
+		if lines[i] != want[i] {
+			t.Errorf("Line %d: expected %q, got %q", i, want[i], lines[i])
+		}
+	}
+}
+
+// TestConsoleJoinedErrors tests that each joined error is listed
+func TestConsoleJoinedErrors(t *testing.T) {
+	lines := consoleErrorLines(errors.Join(errors.New("first"), fmt.Errorf("second: %w", errors.New("inner"))))
+	want := []string{"first\nsecond: inner", "- first (*errors.errorString)", "- second: inner (*fmt.wrapError)", "  caused by: inner (*errors.errorString)"}
+	if strings.Join(lines, "|") != strings.Join(want, "|") {
+		t.Errorf("Expected %q, got %q", want, lines)
+	}
+}
+
+// TestConsoleCallerAndTime tests file:line callers and relative timestamps
+func TestConsoleCallerAndTime(t *testing.T) {
+	var buf bytes.Buffer
+	testLogger := newTestLogger(&buf, withComponent("checkout", "2.1.0"), withConsole(ConsoleConfig{RelativeTime: true}))
+	testLogger.showCaller = true
+
+	testLogger.log(INFO, "hello", nil)
+
+	line := buf.String()
+	if !strings.HasPrefix(line, "+0.0") {
+		t.Errorf("Expected a relative timestamp, got %q", line)
+	}
+	if !strings.Contains(line, "/formatters_console_test.go:") {
+		t.Errorf("Expected a file:line caller, got %q", line)
+	}
+}
+
+// TestConsoleColors tests forced colors and NO_COLOR
+func TestConsoleColors(t *testing.T) {
+	var buf bytes.Buffer
+	testLogger := newTestLogger(&buf, withComponent("checkout", "2.1.0"), withConsole(ConsoleConfig{Color: COLOR_ALWAYS}))
+	testLogger.log(ERROR, "failed", map[string]any{"count": 7})
+
+	if !strings.Contains(buf.String(), ansiRed+"ERROR"+ansiReset) || !strings.Contains(buf.String(), ansiCyan+"count"+ansiReset+"=7") {
+		t.Errorf("Expected colored level and key, got %q", buf.String())
+	}
+
+	t.Setenv("NO_COLOR", "")
+	if newConsoleLayout(ConsoleConfig{}).console.noColor {
+		t.Error("Expected an empty NO_COLOR to be ignored")
+	}
+	t.Setenv("NO_COLOR", "1")
+	if newConsoleLayout(ConsoleConfig{}).console.useColor(os.Stdout) {
+		t.Error("Expected NO_COLOR to disable colors")
+	}
+	if !newConsoleLayout(ConsoleConfig{Color: COLOR_ALWAYS}).console.useColor(&buf) {
+		t.Error("Expected COLOR_ALWAYS to override NO_COLOR")
+	}
+}
@@ -0,0 +1,173 @@
+package emit
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// logfmtLayout writes the built-in logfmt keys then the fields
+var logfmtLayout = &entryLayout{
+	enc: logfmtEncoder{},
+	keys: entryKeys{
+		time:      "ts",
+		level:     "level",
+		message:   "msg",
+		component: "component",
+		version:   "version",
+		caller:    "caller",
+	},
+}
+
+// logfmtEncoder writes entries as logfmt: space separated key=value pairs
+// on one line, quoting values that are empty or contain spaces, '=', '"',
+// control characters or invalid UTF-8
+type logfmtEncoder struct{}
+
+func (logfmtEncoder) begin(buf []byte) []byte {
+	return buf
+}
+
+func (logfmtEncoder) end(buf []byte) []byte {
+	return append(buf, '\n')
+}
+
+// appendKey appends the separator and key, replacing characters that are
+// not allowed in logfmt keys with '_'
+func (logfmtEncoder) appendKey(buf []byte, key string) []byte {
+	if len(buf) > 0 && buf[len(buf)-1] != '\n' {
+		buf = append(buf, ' ')
+	}
+	if key == "" {
+		return append(buf, "_="...)
+	}
+
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if c <= ' ' || c == '=' || c == '"' || c == 0x7f {
+			c = '_'
+		}
+		buf = append(buf, c)
+	}
+	return append(buf, '=')
+}
+
+func (e logfmtEncoder) appendString(buf []byte, key, value string) []byte {
+	buf = e.appendKey(buf, key)
+	return appendLogfmtValue(buf, value)
+}
+
+func (e logfmtEncoder) appendInt(buf []byte, key string, value int64) []byte {
+	buf = e.appendKey(buf, key)
+	return strconv.AppendInt(buf, value, 10)
+}
+
+func (e logfmtEncoder) appendFloat(buf []byte, key string, value float64) []byte {
+	buf = e.appendKey(buf, key)
+	return strconv.AppendFloat(buf, value, 'f', -1, 64)
+}
+
+func (e logfmtEncoder) appendBool(buf []byte, key string, value bool) []byte {
+	buf = e.appendKey(buf, key)
+	return strconv.AppendBool(buf, value)
+}
+
+func (e logfmtEncoder) appendTime(buf []byte, key string, value time.Time) []byte {
+	buf = e.appendKey(buf, key)
+	return value.AppendFormat(buf, time.RFC3339Nano)
+}
+
+func (e logfmtEncoder) appendEntryTime(buf []byte, key string, te *timeEncoder, now time.Time) []byte {
+	buf = e.appendKey(buf, key)
+	if te.quote {
+		return appendLogfmtValue(buf, string(te.append(nil, now)))
+	}
+	return te.append(buf, now)
+}
+
+func (e logfmtEncoder) appendDuration(buf []byte, key string, value time.Duration) []byte {
+	buf = e.appendKey(buf, key)
+	return append(buf, value.String()...)
+}
+
+func (e logfmtEncoder) appendAny(buf []byte, key string, value any) []byte {
+	buf = e.appendKey(buf, key)
+	if value == nil {
+		return append(buf, "null"...)
+	}
+	return appendLogfmtValue(buf, fmt.Sprint(value))
+}
+
+// appendStack writes the frames as one quoted value with escaped newlines
+func (e logfmtEncoder) appendStack(buf []byte, key string, frames []stackFrame) []byte {
+	buf = e.appendKey(buf, key)
+	var scratch [1024]byte
+	return appendLogfmtValue(buf, string(appendStackLines(scratch[:0], frames, "")))
+}
+
+// appendLogfmtValue appends a value, quoted and escaped if needed
+func appendLogfmtValue(buf []byte, value string) []byte {
+	if !logfmtNeedsQuotes(value) {
+		return append(buf, value...)
+	}
+
+	buf = append(buf, '"')
+	for i := 0; i < len(value); {
+		c := value[i]
+		if c < utf8.RuneSelf {
+			switch c {
+			case '"', '\\':
+				buf = append(buf, '\\', c)
+			case '\n':
+				buf = append(buf, '\\', 'n')
+			case '\r':
+				buf = append(buf, '\\', 'r')
+			case '\t':
+				buf = append(buf, '\\', 't')
+			default:
+				if c < ' ' || c == 0x7f {
+					buf = append(buf, '\\', 'u', '0', '0', "0123456789abcdef"[c>>4], "0123456789abcdef"[c&0xF])
+				} else {
+					buf = append(buf, c)
+				}
+			}
+			i++
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(value[i:])
+		if r == utf8.RuneError && size == 1 {
+			buf = append(buf, `�`...)
+		} else {
+			buf = append(buf, value[i:i+size]...)
+		}
+		i += size
+	}
+	return append(buf, '"')
+}
+
+// logfmtNeedsQuotes reports whether a value must be quoted
+func logfmtNeedsQuotes(value string) bool {
+	if value == "" {
+		return true
+	}
+	for i := 0; i < len(value); {
+		c := value[i]
+		if c < utf8.RuneSelf {
+			if c <= ' ' || c == '=' || c == '"' || c == 0x7f {
+				return true
+			}
+			i++
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(value[i:])
+		if r == utf8.RuneError && size == 1 || !unicode.IsPrint(r) {
+			return true
+		}
+		i += size
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+package emit
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestLogfmtFormat tests key ordering, quoting and masking
+func TestLogfmtFormat(t *testing.T) {
+	var buf bytes.Buffer
+	testLogger := newTestLogger(&buf, withFormat(LOGFMT_FORMAT), withComponent("api", "1.2.0"))
+
+	testLogger.log(INFO, "user signed in", map[string]any{
+		"zone":     "eu-west",
+		"attempts": 3,
+		"password": "hunter2",
+		"email":    "jane@example.com",
+		"request":  map[string]any{"path": "/login", "latency": 1500 * time.Millisecond},
+		"note":     `say "hi"` + "\n",
+		"empty":    "",
+		"ratio":    0.25,
+		"ok":       true,
+		"missing":  nil,
+	})
+
+	line := buf.String()
+	if !strings.HasPrefix(line, "ts=") || !strings.HasSuffix(line, "\n") {
+		t.Fatalf("Unexpected logfmt line: %q", line)
+	}
+
+	// Timestamp varies, everything after it is deterministic
+	rest := line[strings.Index(line, " level="):]
+	want := ` level=info msg="user signed in" component=api version=1.2.0` +
+		` attempts=3 email=***PII*** empty="" missing=null note="say \"hi\"\n" ok=true password=***MASKED***` +
+		` ratio=0.25 request.latency=1.5s request.path=/login zone=eu-west` + "\n"
+	if rest != want {
+		t.Errorf("Unexpected logfmt entry:\n got: %q\nwant: %q", rest, want)
+	}
+}
+
+// TestLogfmtStructuredFields tests the structured path and its masking
+func TestLogfmtStructuredFields(t *testing.T) {
+	var buf bytes.Buffer
+	testLogger := newTestLogger(&buf, withFormat(LOGFMT_FORMAT), withComponent("api", "1.2.0"))
+	testLogger.component, testLogger.version = "", ""
+
+	testLogger.logStructuredFields(WARN, "retry",
+		ZString("password", "hunter2"),
+		ZString("key with space", "a=b"),
+		ZInt64("bytes", 1<<40),
+		ZDuration("backoff", 250*time.Millisecond),
+		ZBool("final", false),
+	)
+	testLogger.log(ERROR, "no fields", nil)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %q", buf.String())
+	}
+	if !strings.HasSuffix(lines[0], ` level=warn msg=retry password=***MASKED*** key_with_space="a=b" bytes=1099511627776 backoff=250ms final=false`) {
+		t.Errorf("Unexpected structured logfmt entry: %q", lines[0])
+	}
+	if !strings.HasSuffix(lines[1], ` level=error msg="no fields"`) {
+		t.Errorf("Unexpected simple logfmt entry: %q", lines[1])
+	}
+}
+
+// TestLogfmtQuoting tests which values are quoted
+func TestLogfmtQuoting(t *testing.T) {
+	tests := map[string]string{
+		"plain":       "plain",
+		"":            `""`,
+		"a b":         `"a b"`,
+		"k=v":         `"k=v"`,
+		"tab\there":   `"tab\there"`,
+		"bell\x07":    `"bell\u0007"`,
+		"héllo":       "héllo",
+		"bad\xffutf8": `"bad�utf8"`,
+		`back\slash`:  `back\slash`,
+	}
+	for value, want := range tests {
+		if got := string(appendLogfmtValue(nil, value)); got != want {
+			t.Errorf("appendLogfmtValue(%q) = %s, want %s", value, got, want)
+		}
+	}
+}
+
+// TestLogfmtStructuredNoAllocation tests that the structured logfmt path does not allocate
+func TestLogfmtStructuredNoAllocation(t *testing.T) {
+	testLogger := newTestLogger(io.Discard, withLevel(INFO), withFormat(LOGFMT_FORMAT))
+
+	fields := []ZField{ZString("k", "v"), ZInt("n", 1), ZFloat64("f", 1.5)}
+	allocs := testing.AllocsPerRun(100, func() {
+		testLogger.logStructuredFields(INFO, "hot path", fields...)
+	})
+	if allocs != 0 {
+		t.Errorf("Expected 0 allocations, got %v", allocs)
+	}
+}
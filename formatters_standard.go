@@ -9,8 +9,20 @@ import (
 
 // logJSON writes a JSON formatted log entry
 func (l *Logger) logJSON(level LogLevel, message string, fields map[string]any) {
+	if l.profile != nil {
+		l.encodeEntry(l.profile, level, message, fields, nil)
+		return
+	}
+	te := l.times()
+	if te.numeric() || l.wantsStack(level) || l.enrichment != nil && l.enrichment.goroutine {
+		l.encodeEntry(logEntryLayout, level, message, fields, nil)
+		return
+	}
+
+	start := l.encodeStart()
+
 	entry := LogEntry{
-		Timestamp: GetUltraFastTimestamp(),
+		Timestamp: string(te.append(nil, l.now())),
 		Level:     level.StringFast(),
 		Message:   message,
 	}
@@ -25,31 +37,37 @@ func (l *Logger) logJSON(level LogLevel, message string, fields map[string]any)
 
 	if len(fields) > 0 {
 		entry.Fields = l.maskSensitiveFieldsFast(fields)
+		if l.metrics != nil {
+			l.fieldsMasked(level, l.countMaskedFields(fields))
+		}
 	}
 
 	if l.showCaller {
-		if pc, file, line, ok := runtime.Caller(4); ok {
-			entry.File = file
-			entry.Line = line
-			if fn := runtime.FuncForPC(pc); fn != nil {
-				entry.Function = fn.Name()
-			}
+		if frame, ok := l.caller(); ok {
+			entry.File = l.callerFile(frame.File)
+			entry.Line = frame.Line
+			entry.Function = frame.Function
 		}
 	}
 
 	data, err := json.Marshal(entry)
 	if err != nil {
 		// Fallback to simple format if JSON marshaling fails
-		_, _ = fmt.Fprintf(l.writer, `{"timestamp":"%s","level":"error","message":"Failed to marshal log entry: %v","component":"%s"}`+"\n",
-			GetUltraFastTimestamp(), err, l.component)
+		l.write(level, fmt.Appendf(nil, `{"timestamp":"%s","level":"error","message":"Failed to marshal log entry: %v","component":"%s"}`+"\n",
+			GetUltraFastTimestamp(), err, l.component), start)
 		return
 	}
 
-	_, _ = fmt.Fprintln(l.writer, string(data))
+	if enriched := l.enrichedJSON(); len(enriched) > 0 {
+		data = append(data[:len(data)-1], enriched...)
+		data = append(data, '}')
+	}
+	l.write(level, append(data, '\n'), start)
 }
 
 // logPlain writes a plain text formatted log entry
 func (l *Logger) logPlain(level LogLevel, message string, fields map[string]any) {
+	start := l.encodeStart()
 	severity := level.String()
 
 	var colorCode string
@@ -78,6 +96,9 @@ func (l *Logger) logPlain(level LogLevel, message string, fields map[string]any)
 	finalMessage := message
 	if len(fields) > 0 {
 		maskedFields := l.maskSensitiveFieldsFast(fields)
+		if l.metrics != nil {
+			l.fieldsMasked(level, l.countMaskedFields(fields))
+		}
 		var fieldParts []string
 		for k, v := range maskedFields {
 			fieldParts = append(fieldParts, fmt.Sprintf("%s=%v", k, v))
@@ -87,16 +108,25 @@ func (l *Logger) logPlain(level LogLevel, message string, fields map[string]any)
 
 	// Console output format:
 	// {UTC TIME} | {LOGGING LEVEL} | {COMPONENT} {VERSION}: {MESSAGE}
-	_, _ = fmt.Fprintf(l.writer, "%s | %s%-7s%s | %s %s: %s\n",
-		GetUltraFastTimestamp()[:19],
-		colorCode, severity, resetCode, l.component, l.version, finalMessage)
+	// or with the caller:
+	// {UTC TIME} | {LOGGING LEVEL} | {COMPONENT} {VERSION} | {CALLER}: {MESSAGE}
+	var caller string
+	if l.showCaller {
+		if frame, ok := l.caller(); ok {
+			caller = " | " + l.trimmedCallerLine(frame)
+		}
+	}
+	timestamp := l.times().append(nil, l.now())
+	entry := fmt.Appendf(timestamp, " | %s%-7s%s | %s %s%s: %s\n",
+		colorCode, severity, resetCode, l.component, l.version, caller, finalMessage)
+	l.write(level, l.appendStackText(entry, level), start)
 }
 
 // buildSimpleJSONUltraFast - Ultra-fast JSON builder for simple messages
 func (l *Logger) buildSimpleJSONUltraFast(buf []byte, level LogLevel, message string) int {
-	timestamp := GetUltraFastTimestamp()
 	levelStr := level.StringFast()
 
+	te := l.times()
 	pos := 0
 
 	// Check buffer space as we build to prevent overflow
@@ -105,15 +135,25 @@ func (l *Logger) buildSimpleJSONUltraFast(buf []byte, level LogLevel, message st
 	}
 	pos += copy(buf[pos:], `{"timestamp":"`)
 
-	if pos+len(timestamp) >= len(buf) {
+	// Epoch timestamps are numbers, so drop the quotes around them
+	levelKey := `","level":"`
+	if te.numeric() {
+		pos--
+		levelKey = levelKey[1:]
+	}
+
+	if pos+te.maxLen >= len(buf) {
+		return len(buf)
+	}
+	if te.escape {
 		return len(buf)
 	}
-	pos += copy(buf[pos:], timestamp)
+	pos += len(te.append(buf[pos:pos], l.now()))
 
-	if pos+len(`","level":"`) >= len(buf) {
+	if pos+len(levelKey) >= len(buf) {
 		return len(buf)
 	}
-	pos += copy(buf[pos:], `","level":"`)
+	pos += copy(buf[pos:], levelKey)
 
 	if pos+len(levelStr) >= len(buf) {
 		return len(buf)
@@ -169,6 +209,13 @@ func (l *Logger) buildSimpleJSONUltraFast(buf []byte, level LogLevel, message st
 		pos += copy(buf[pos:], `"`)
 	}
 
+	if enriched := l.enrichedJSON(); len(enriched) > 0 {
+		if pos+len(enriched) >= len(buf) {
+			return len(buf)
+		}
+		pos += copy(buf[pos:], enriched)
+	}
+
 	if pos+len("}\n") >= len(buf) {
 		return len(buf)
 	}
@@ -178,16 +225,16 @@ func (l *Logger) buildSimpleJSONUltraFast(buf []byte, level LogLevel, message st
 
 // buildSimplePlainUltraFast - Ultra-fast plain text builder for simple messages
 func (l *Logger) buildSimplePlainUltraFast(buf []byte, level LogLevel, message string) int {
-	timestamp := GetUltraFastTimestamp()
+	te := l.times()
 	levelStr := level.StringFast()
 
 	pos := 0
 
 	// Check buffer space as we build to prevent overflow
-	if pos+19 >= len(buf) {
+	if pos+te.maxLen >= len(buf) {
 		return len(buf)
-	} // timestamp[:19]
-	pos += copy(buf[pos:], timestamp[:19])
+	}
+	pos += len(te.append(buf[pos:pos], l.now()))
 
 	if pos+3 >= len(buf) {
 		return len(buf)
@@ -53,13 +53,56 @@ var (
 	versionPrefix   = []byte(`,"version":"`)
 )
 
-// logStructuredFields - optimized for maximum performance with thread-safe buffers
+// logStructuredFields - level check and admission for the structured fields path
 func (l *Logger) logStructuredFields(level LogLevel, message string, fields ...ZField) {
 	// Ultra-fast level check - most critical optimization
-	if level < l.level {
+	if level < l.level || !l.admit(level, message) {
 		return
 	}
 
+	// Hooks only see an Entry when registered - no allocation otherwise
+	if l.hooks != nil {
+		var ok bool
+		if message, fields, ok = l.runStructuredHooks(level, message, fields); !ok {
+			return
+		}
+	}
+
+	l.writeStructuredFields(level, message, fields...)
+}
+
+// fastZFields reports whether the fast path below can write every field;
+// the encoder writes the other built-in types (Int64, Time and Duration)
+func fastZFields(fields []ZField) bool {
+	for _, field := range fields {
+		switch field.(type) {
+		case StringZField, IntZField, Float64ZField, BoolZField:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// writeStructuredFields - optimized for maximum performance with thread-safe buffers
+func (l *Logger) writeStructuredFields(level LogLevel, message string, fields ...ZField) {
+	if layout := l.formatLayout(); layout != nil {
+		l.encodeEntry(layout, level, message, nil, fields)
+		return
+	}
+	if l.profile != nil {
+		l.encodeEntry(l.profile, level, message, nil, fields)
+		return
+	}
+	te := l.times()
+	if te.escape || l.showCaller || l.wantsStack(level) || l.enrichment != nil && l.enrichment.goroutine || !fastZFields(fields) {
+		l.encodeEntry(logEntryLayout, level, message, nil, fields)
+		return
+	}
+
+	start := l.encodeStart()
+	masked := 0
+
 	// Get thread-safe buffer from pool to prevent race conditions
 	bufPtr := bufferPool.Get().(*[]byte)
 	buf := *bufPtr
@@ -69,9 +112,10 @@ func (l *Logger) logStructuredFields(level LogLevel, message string, fields ...Z
 	// Hot path optimization: For common case (≤4 fields), skip estimation
 	// Most logging calls have 0-4 fields, so this covers 95%+ of cases
 	fieldCount := len(fields)
-	if fieldCount > 4 || len(message) > 200 {
+	enriched := l.enrichedJSON()
+	if fieldCount > 4 || len(message) > 200 || te.maxLen > maxTimestampLen || len(enriched) > 128 {
 		// Only do estimation for complex cases
-		estimatedSize := 100 + len(message)
+		estimatedSize := 100 + len(message) + te.maxLen + len(enriched)
 
 		if l.component != "" {
 			estimatedSize += 15 + len(l.component)
@@ -110,16 +154,21 @@ func (l *Logger) logStructuredFields(level LogLevel, message string, fields ...Z
 	copy(buf[pos:], []byte(`mestamp":"`))
 	pos += 10
 
-	// Fast cached timestamp - inline string copy
-	ts := GetUltraFastTimestamp()
-	copy(buf[pos:], ts)
-	pos += len(ts)
+	// Epoch timestamps are numbers: drop the opening quote, and the
+	// closing one that starts the level bytes
+	skip := 0
+	if te.numeric() {
+		pos--
+		skip = 1
+	}
+
+	// Timestamp appended in place by the logger's time encoder
+	pos += len(te.append(buf[pos:pos], l.now()))
 
 	// Level section - pre-computed byte slices, eliminate switch overhead for INFO
 	if level == INFO {
 		// Most common case - hardcode for INFO
-		copy(buf[pos:], infoLevelBytes)
-		pos += len(infoLevelBytes)
+		pos += copy(buf[pos:], infoLevelBytes[skip:])
 	} else {
 		var levelBytes []byte
 		switch level {
@@ -132,8 +181,7 @@ func (l *Logger) logStructuredFields(level LogLevel, message string, fields ...Z
 		default:
 			levelBytes = infoLevelBytes
 		}
-		copy(buf[pos:], levelBytes)
-		pos += len(levelBytes)
+		pos += copy(buf[pos:], levelBytes[skip:])
 	}
 
 	// Message (inline string-to-byte conversion)
@@ -163,8 +211,8 @@ func (l *Logger) logStructuredFields(level LogLevel, message string, fields ...Z
 
 			// Security check inline - optimize for non-sensitive case
 			if f.IsSensitive() || f.IsPII() {
-				copy(buf[pos:], "***MASKED***")
-				pos += 12
+				pos += copy(buf[pos:], zfieldMask)
+				masked++
 			} else {
 				// Properly escape JSON strings to prevent invalid JSON
 				escaped := escapeJSONString(buf[pos:], f.Value)
@@ -262,19 +310,29 @@ func (l *Logger) logStructuredFields(level LogLevel, message string, fields ...Z
 		pos++
 	}
 
+	// Add pre-encoded enrichment metadata
+	pos += copy(buf[pos:], enriched)
+
 	// Close JSON: }\n - inline for final micro-optimization
 	buf[pos] = '}'
 	buf[pos+1] = '\n'
 	pos += 2
 
 	// Single write operation
-	_, _ = l.writer.Write(buf[:pos])
+	l.fieldsMasked(level, masked)
+	l.write(level, buf[:pos], start)
 }
 
 // logStructuredFieldsDynamic - handles cases where log entry is too large for stack buffer
 func (l *Logger) logStructuredFieldsDynamic(level LogLevel, message string, fields ...ZField) {
+	start := l.encodeStart()
+	masked := 0
+
+	te := l.times()
+
 	// Calculate required size more accurately
-	size := 100 + len(message) // base structure + message
+	size := 100 + len(message) + te.maxLen // base structure + message
+	size += len(l.enrichedJSON())
 
 	// Add logger metadata
 	if l.component != "" {
@@ -315,9 +373,12 @@ func (l *Logger) logStructuredFieldsDynamic(level LogLevel, message string, fiel
 	copy(buf[pos:], []byte(`mestamp":"`))
 	pos += 10
 
-	ts := GetUltraFastTimestamp()
-	copy(buf[pos:], ts)
-	pos += len(ts)
+	skip := 0
+	if te.numeric() {
+		pos--
+		skip = 1
+	}
+	pos += len(te.append(buf[pos:pos], l.now()))
 
 	// Level
 	var levelBytes []byte
@@ -334,8 +395,7 @@ func (l *Logger) logStructuredFieldsDynamic(level LogLevel, message string, fiel
 		levelBytes = infoLevelBytes
 	}
 
-	copy(buf[pos:], levelBytes)
-	pos += len(levelBytes)
+	pos += copy(buf[pos:], levelBytes[skip:])
 
 	copy(buf[pos:], message)
 	pos += len(message)
@@ -356,8 +416,8 @@ func (l *Logger) logStructuredFieldsDynamic(level LogLevel, message string, fiel
 			pos += 3
 
 			if f.IsSensitive() || f.IsPII() {
-				copy(buf[pos:], "***MASKED***")
-				pos += 12
+				pos += copy(buf[pos:], zfieldMask)
+				masked++
 			} else {
 				// Properly escape JSON strings in dynamic formatter too
 				escaped := escapeJSONString(buf[pos:], f.Value)
@@ -442,12 +502,15 @@ func (l *Logger) logStructuredFieldsDynamic(level LogLevel, message string, fiel
 		pos++
 	}
 
+	pos += copy(buf[pos:], l.enrichedJSON())
+
 	// Close JSON: }\n
 	buf[pos] = '}'
 	buf[pos+1] = '\n'
 	pos += 2
 
-	_, _ = l.writer.Write(buf[:pos])
+	l.fieldsMasked(level, masked)
+	l.write(level, buf[:pos], start)
 }
 
 // Route structured fields to implementation
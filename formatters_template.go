@@ -0,0 +1,241 @@
+package emit
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// templateVerb identifies one part of a plain layout template
+type templateVerb int
+
+const (
+	verbLiteral templateVerb = iota
+	verbTime
+	verbLevel
+	verbComponent
+	verbVersion
+	verbCaller
+	verbMessage
+	verbFields
+)
+
+// templateVerbs maps template verb names to verbs
+var templateVerbs = map[string]templateVerb{
+	"time":      verbTime,
+	"level":     verbLevel,
+	"component": verbComponent,
+	"version":   verbVersion,
+	"caller":    verbCaller,
+	"message":   verbMessage,
+	"fields":    verbFields,
+}
+
+// templatePart is one precompiled appender of a template
+type templatePart struct {
+	verb    templateVerb
+	literal string // Text (verbLiteral) or time layout (verbTime)
+	upper   bool   // Upper case level names
+	width   int    // Pad the level to this width
+	full    bool   // Full caller path instead of dir/file
+}
+
+// parsePlainTemplate compiles a plain text layout template such as
+//
+//	"%time{15:04:05.000} %level{upper,5} [%component] %caller %message %fields"
+//
+// Verbs are %time (with an optional Go time layout, in the time encoder's
+// zone), %level (with upper and/or a width), %component, %version, %caller
+// (dir/file:line, or the full path with {full}), %message and %fields
+// (space separated key=value pairs in key order, quoted when needed); %%
+// writes a percent sign. Spaces that end a line after verbs with nothing
+// to write are trimmed.
+func parsePlainTemplate(template string) (*entryLayout, error) {
+	var parts []templatePart
+	literal := strings.Builder{}
+	flush := func() {
+		if literal.Len() > 0 {
+			parts = append(parts, templatePart{verb: verbLiteral, literal: literal.String()})
+			literal.Reset()
+		}
+	}
+
+	for i := 0; i < len(template); i++ {
+		c := template[i]
+		if c != '%' {
+			literal.WriteByte(c)
+			continue
+		}
+		if i+1 < len(template) && template[i+1] == '%' {
+			literal.WriteByte('%')
+			i++
+			continue
+		}
+
+		end := i + 1
+		for end < len(template) && template[end] >= 'a' && template[end] <= 'z' {
+			end++
+		}
+		name := template[i+1 : end]
+		verb, ok := templateVerbs[name]
+		if !ok {
+			return nil, fmt.Errorf("emit: unknown template verb %q at offset %d", "%"+name, i)
+		}
+
+		var args string
+		if end < len(template) && template[end] == '{' {
+			closing := strings.IndexByte(template[end:], '}')
+			if closing < 0 {
+				return nil, fmt.Errorf("emit: unterminated arguments of %%%s at offset %d", name, i)
+			}
+			args = template[end+1 : end+closing]
+			end += closing + 1
+		}
+
+		part, err := newTemplatePart(verb, args)
+		if err != nil {
+			return nil, fmt.Errorf("emit: %%%s: %w", name, err)
+		}
+		flush()
+		parts = append(parts, part)
+		i = end - 1
+	}
+	flush()
+
+	return &entryLayout{enc: logfmtEncoder{}, shape: shapeTemplate, template: parts}, nil
+}
+
+// newTemplatePart builds the appender of a verb from its arguments
+func newTemplatePart(verb templateVerb, args string) (templatePart, error) {
+	part := templatePart{verb: verb}
+	switch verb {
+	case verbTime:
+		part.literal = args
+		return part, nil
+
+	case verbLevel:
+		if args == "" {
+			return part, nil
+		}
+		for _, arg := range strings.Split(args, ",") {
+			arg = strings.TrimSpace(arg)
+			switch arg {
+			case "upper":
+				part.upper = true
+			case "lower":
+				part.upper = false
+			default:
+				width, err := strconv.Atoi(arg)
+				if err != nil || width < 0 {
+					return part, fmt.Errorf("invalid argument %q", arg)
+				}
+				part.width = width
+			}
+		}
+		return part, nil
+
+	case verbCaller:
+		switch args {
+		case "":
+		case "full":
+			part.full = true
+		default:
+			return part, fmt.Errorf("invalid argument %q", args)
+		}
+		return part, nil
+
+	default:
+		if args != "" {
+			return part, fmt.Errorf("takes no arguments")
+		}
+		return part, nil
+	}
+}
+
+// SetPlainTemplate sets the layout of the default logger's plain format,
+// used by every logging API; an empty template restores the built-in layout
+func SetPlainTemplate(template string) error {
+	if template == "" {
+		if defaultLogger != nil {
+			defaultLogger.template = nil
+		}
+		return nil
+	}
+
+	layout, err := parsePlainTemplate(template)
+	if err != nil {
+		return err
+	}
+	if defaultLogger != nil {
+		defaultLogger.template = layout
+	}
+	return nil
+}
+
+// appendTemplate appends an entry laid out by a compiled template
+func (k *entryLayout) appendTemplate(l *Logger, buf []byte, level LogLevel, message string, fields map[string]any, zfields []ZField) ([]byte, int) {
+	masked := 0
+	written := len(buf) // End of the last verb's output
+	for i := range k.template {
+		part := &k.template[i]
+		start := len(buf)
+		switch part.verb {
+		case verbLiteral:
+			buf = append(buf, part.literal...)
+			continue
+
+		case verbTime:
+			if part.literal == "" {
+				buf = l.times().append(buf, l.now())
+			} else {
+				buf = l.now().In(l.times().location).AppendFormat(buf, part.literal)
+			}
+
+		case verbLevel:
+			name := level.StringFast()
+			if part.upper {
+				name = upperLevel(level)
+			}
+			buf = append(buf, name...)
+			for pad := part.width - len(name); pad > 0; pad-- {
+				buf = append(buf, ' ')
+			}
+
+		case verbComponent:
+			buf = append(buf, l.component...)
+
+		case verbVersion:
+			buf = append(buf, l.version...)
+
+		case verbCaller:
+			if frame, ok := l.caller(); ok {
+				if part.full {
+					buf = append(buf, frame.File...)
+					buf = append(buf, ':')
+					buf = strconv.AppendInt(buf, int64(frame.Line), 10)
+				} else {
+					buf = append(buf, l.trimmedCallerLine(frame)...)
+				}
+			}
+
+		case verbMessage:
+			buf = append(buf, message...)
+
+		case verbFields:
+			// The encoder separates pairs with a space unless the buffer is
+			// empty, so the fields start on an empty slice of the buffer
+			var out []byte
+			out, masked = l.appendUserFields(k.enc, buf[len(buf):], fields, zfields, nil)
+			buf = append(buf, out...)
+		}
+		if len(buf) > start {
+			written = len(buf)
+		}
+	}
+
+	// Verbs with nothing to write must not leave the spaces after them
+	for len(buf) > written && buf[len(buf)-1] == ' ' {
+		buf = buf[:len(buf)-1]
+	}
+	return l.appendStackText(append(buf, '\n'), level), masked
+}
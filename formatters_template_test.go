@@ -0,0 +1,96 @@
+package emit
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// TestPlainTemplate tests a template across the logging APIs
+func TestPlainTemplate(t *testing.T) {
+	var buf bytes.Buffer
+	testLogger := newTestLogger(&buf, withComponent("checkout", "2.1.0"), withTemplate(t, "%time{15:04:05.000} %level{upper,5} [%component %version] %caller %message %fields"))
+
+	testLogger.log(WARN, "disk low", nil)
+	testLogger.log(INFO, "order", map[string]any{"zeta": 1, "alpha": "two words", "password": "x"})
+	testLogger.logStructuredFields(ERROR, "failed", ZString("token", "abc"), ZInt("attempt", 3))
+
+	patterns := []string{
+		`^\d\d:\d\d:\d\d\.\d{3} WARN  \[checkout 2\.1\.0\] \S+/formatters_template_test\.go:\d+ disk low$`,
+		`^\d\d:\d\d:\d\d\.\d{3} INFO  \[checkout 2\.1\.0\] \S+/formatters_template_test\.go:\d+ order alpha="two words" password=\*\*\*MASKED\*\*\* zeta=1$`,
+		`^\d\d:\d\d:\d\d\.\d{3} ERROR \[checkout 2\.1\.0\] \S+/formatters_template_test\.go:\d+ failed token=\*\*\*MASKED\*\*\* attempt=3$`,
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != len(patterns) {
+		t.Fatalf("Expected %d lines, got %q", len(patterns), buf.String())
+	}
+	for i, pattern := range patterns {
+		if !regexp.MustCompile(pattern).MatchString(lines[i]) {
+			t.Errorf("Line %q does not match %s", lines[i], pattern)
+		}
+	}
+}
+
+// TestPlainTemplateLiterals tests escapes, empty verbs and default arguments
+func TestPlainTemplateLiterals(t *testing.T) {
+	var buf bytes.Buffer
+	testLogger := newTestLogger(&buf, withComponent("checkout", "2.1.0"), withTemplate(t, "100%% %level: %message %fields %version"))
+	testLogger.version = ""
+
+	testLogger.log(DEBUG, "ready", nil)
+	testLogger.log(DEBUG, "ready", map[string]any{"n": 1})
+
+	if want := "100% debug: ready\n100% debug: ready n=1\n"; buf.String() != want {
+		t.Errorf("Expected %q, got %q", want, buf.String())
+	}
+}
+
+// TestPlainTemplateSpacing tests that %fields gets no leading separator and
+// that only the template's own spaces are trimmed
+func TestPlainTemplateSpacing(t *testing.T) {
+	var buf bytes.Buffer
+	testLogger := newTestLogger(&buf, withTemplate(t, "[%fields] %message %component"))
+
+	testLogger.log(INFO, "hi", map[string]any{"a": "b", "c": "d"})
+	testLogger.logStructuredFields(INFO, "padded  ", ZString("a", "b"))
+	testLogger.log(INFO, "bare", nil)
+
+	if want := "[a=b c=d] hi\n[a=b] padded  \n[] bare\n"; buf.String() != want {
+		t.Errorf("Expected %q, got %q", want, buf.String())
+	}
+}
+
+// TestPlainTemplateErrors tests that invalid templates are rejected
+func TestPlainTemplateErrors(t *testing.T) {
+	for _, template := range []string{
+		"%unknown",
+		"%level{upper",
+		"%level{wide}",
+		"%caller{short}",
+		"%message{x}",
+	} {
+		if _, err := parsePlainTemplate(template); err == nil {
+			t.Errorf("Expected %q to fail", template)
+		}
+	}
+}
+
+// TestPlainTemplateNoAllocation tests that templates keep the simple and
+// structured paths allocation-free
+func TestPlainTemplateNoAllocation(t *testing.T) {
+	if raceEnabled {
+		t.Skip("sync.Pool drops items under the race detector")
+	}
+	testLogger := newTestLogger(io.Discard, withComponent("checkout", "2.1.0"), withTemplate(t, "%time{15:04:05.000} %level{upper,5} [%component] %message %fields"))
+
+	fields := []ZField{ZString("method", "GET"), ZInt("status", 200)}
+	allocs := testing.AllocsPerRun(100, func() {
+		testLogger.log(INFO, "simple message", nil)
+		testLogger.logStructuredFields(INFO, "request", fields...)
+	})
+	if allocs != 0 {
+		t.Errorf("Expected 0 allocations, got %v", allocs)
+	}
+}
@@ -0,0 +1,122 @@
+package emit
+
+import (
+	"io"
+	"testing"
+)
+
+// testOption configures a logger built by newTestLogger
+type testOption func(*Logger)
+
+// newTestLogger returns a DEBUG logger writing JSON to w with the default
+// masking rules, then applies options
+func newTestLogger(w io.Writer, options ...testOption) *Logger {
+	testLogger := &Logger{
+		level:           DEBUG,
+		writer:          w,
+		format:          JSON_FORMAT,
+		sensitiveMode:   MASK_SENSITIVE,
+		piiMode:         MASK_PII,
+		sensitiveFields: defaultSensitiveFields,
+		piiFields:       defaultPIIFields,
+		maskString:      "***MASKED***",
+		piiMaskString:   "***PII***",
+	}
+	for _, option := range options {
+		option(testLogger)
+	}
+	return testLogger
+}
+
+// useTestLogger makes a new test logger the default logger for one test
+func useTestLogger(t *testing.T, w io.Writer, options ...testOption) *Logger {
+	testLogger := newTestLogger(w, options...)
+	originalLogger := defaultLogger
+	defaultLogger = testLogger
+	t.Cleanup(func() { defaultLogger = originalLogger })
+	return testLogger
+}
+
+// withLevel sets the minimum level
+func withLevel(level LogLevel) testOption {
+	return func(l *Logger) { l.level = level }
+}
+
+// withFormat sets the output format
+func withFormat(format OutputFormat) testOption {
+	return func(l *Logger) { l.format = format }
+}
+
+// withComponent sets the component and version
+func withComponent(component, version string) testOption {
+	return func(l *Logger) { l.component, l.version = component, version }
+}
+
+// withCaller adds the caller to entries
+func withCaller() testOption {
+	return func(l *Logger) { l.showCaller = true }
+}
+
+// withTimeEncoder sets how timestamps are written
+func withTimeEncoder(encoder TimeEncoder) testOption {
+	return func(l *Logger) { l.timeEncoder = newTimeEncoder(encoder) }
+}
+
+// withClock sets the clock
+func withClock(clock Clock) testOption {
+	return func(l *Logger) { l.clock = clock }
+}
+
+// withWriteErrors configures write error handling
+func withWriteErrors(config WriteErrorConfig) testOption {
+	return func(l *Logger) { l.writeErrors = newWriteErrorState(config) }
+}
+
+// withSampling enables sampling
+func withSampling(config SamplingConfig) testOption {
+	return func(l *Logger) { l.sampler = newSampler(config) }
+}
+
+// withHooks registers hooks
+func withHooks(hooks ...registeredHook) testOption {
+	return func(l *Logger) {
+		for _, hook := range hooks {
+			l.hooks = l.hooks.with(hook)
+		}
+	}
+}
+
+// withProfile sets a JSON profile by name ("" for none)
+func withProfile(profile string) testOption {
+	return func(l *Logger) { l.profile = parseProfile(profile) }
+}
+
+// withEncoderConfig sets a configured JSON layout
+func withEncoderConfig(config EncoderConfig) testOption {
+	return func(l *Logger) { l.profile = newConfiguredLayout(config) }
+}
+
+// withTemplate switches to the plain format laid out by template
+func withTemplate(t *testing.T, template string) testOption {
+	t.Helper()
+	layout, err := parsePlainTemplate(template)
+	if err != nil {
+		t.Fatalf("Template %q failed to compile: %v", template, err)
+	}
+	return func(l *Logger) { l.format, l.template = PLAIN_FORMAT, layout }
+}
+
+// withConsole switches to the console format
+func withConsole(config ConsoleConfig) testOption {
+	return func(l *Logger) { l.format, l.console = CONSOLE_FORMAT, newConsoleLayout(config) }
+}
+
+// withStackTraces attaches stack traces from ERROR
+func withStackTraces(trimRuntime bool) testOption {
+	return func(l *Logger) { l.stacks = &stackOptions{level: ERROR, trimRuntime: trimRuntime, maxFrames: 32} }
+}
+
+// withEnrichment adds metadata to structured entries
+func withEnrichment(config EnrichmentConfig) testOption {
+	return func(l *Logger) { l.enrichment = newEnrichment(config) }
+}
@@ -0,0 +1,426 @@
+package emit
+
+import (
+	"fmt"
+	"maps"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Hook is invoked for every entry between building it and writing it.
+// Returning false vetoes the entry. Hooks run synchronously on the logging
+// goroutine and must not retain the entry after Fire returns.
+type Hook interface {
+	Fire(entry *Entry) bool
+}
+
+// HookFunc adapts a function to the Hook interface
+type HookFunc func(entry *Entry) bool
+
+// Fire calls f(entry)
+func (f HookFunc) Fire(entry *Entry) bool {
+	return f(entry)
+}
+
+// Entry is the view of a log entry passed to hooks.
+//
+// Field values read through Field, Fields and Range are masked with the
+// logger's sensitive and PII rules, so hooks can forward them safely.
+// Fields set by hooks are masked again when the entry is written. Structured
+// fields keep their ZField types (a ZTime stays a time.Time); custom ZField
+// types are not listed but are passed on to the writer unchanged.
+type Entry struct {
+	logger  *Logger
+	level   LogLevel
+	message string
+
+	// Exactly one representation is used, matching the API that logged the entry
+	fields  map[string]any
+	zfields []ZField
+
+	// Copy-on-write: the caller's map or slice is never modified
+	owned bool
+}
+
+// Level returns the entry level
+func (e *Entry) Level() LogLevel {
+	return e.level
+}
+
+// Message returns the entry message
+func (e *Entry) Message() string {
+	return e.message
+}
+
+// Component returns the logger component
+func (e *Entry) Component() string {
+	return e.logger.component
+}
+
+// Caller returns the frame that logged the entry
+func (e *Entry) Caller() (runtime.Frame, bool) {
+	// The stack is: hook frames, emit frames, then the logging call site
+	return callerFrame(1, e.logger.callerSkip)
+}
+
+// isEmitFrame reports whether a frame belongs to emit itself (tests excluded)
+func isEmitFrame(frame runtime.Frame) bool {
+	return strings.HasPrefix(frame.Function, "github.com/cloudresty/emit.") &&
+		!strings.HasSuffix(frame.File, "_test.go")
+}
+
+// Field returns the (masked) value of a field
+func (e *Entry) Field(key string) (any, bool) {
+	if e.zfields != nil {
+		for _, field := range e.zfields {
+			if zfieldKey(field) == key {
+				return e.logger.maskZFieldValue(field), true
+			}
+		}
+		return nil, false
+	}
+
+	value, ok := e.fields[key]
+	if !ok {
+		return nil, false
+	}
+	return e.logger.maskFieldValue(key, value), true
+}
+
+// Range calls fn for each field with its (masked) value until fn returns false
+func (e *Entry) Range(fn func(key string, value any) bool) {
+	if e.zfields != nil {
+		for _, field := range e.zfields {
+			// Custom ZField types have no key or value to show
+			key := zfieldKey(field)
+			if key == "" {
+				continue
+			}
+			if !fn(key, e.logger.maskZFieldValue(field)) {
+				return
+			}
+		}
+		return
+	}
+
+	for key, value := range e.fields {
+		if !fn(key, e.logger.maskFieldValue(key, value)) {
+			return
+		}
+	}
+}
+
+// Fields returns a copy of the entry fields with masked values
+func (e *Entry) Fields() map[string]any {
+	fields := make(map[string]any, len(e.fields)+len(e.zfields))
+	e.Range(func(key string, value any) bool {
+		fields[key] = value
+		return true
+	})
+	return fields
+}
+
+// SetMessage replaces the entry message
+func (e *Entry) SetMessage(message string) {
+	e.message = message
+}
+
+// SetField adds a field or replaces an existing one
+func (e *Entry) SetField(key string, value any) {
+	e.own()
+
+	if e.zfields != nil {
+		field := toZField(key, value)
+		for i := range e.zfields {
+			if zfieldKey(e.zfields[i]) == key {
+				e.zfields[i] = field
+				return
+			}
+		}
+		e.zfields = append(e.zfields, field)
+		return
+	}
+
+	e.fields[key] = value
+}
+
+// DeleteField removes a field
+func (e *Entry) DeleteField(key string) {
+	e.own()
+
+	if e.zfields != nil {
+		e.zfields = slices.DeleteFunc(e.zfields, func(field ZField) bool {
+			return zfieldKey(field) == key
+		})
+		return
+	}
+
+	delete(e.fields, key)
+}
+
+// own copies the caller's fields before the first mutation
+func (e *Entry) own() {
+	if e.owned {
+		return
+	}
+	e.owned = true
+
+	if e.zfields != nil {
+		e.zfields = slices.Clone(e.zfields)
+		return
+	}
+
+	fields := make(map[string]any, len(e.fields)+1)
+	maps.Copy(fields, e.fields)
+	e.fields = fields
+}
+
+// zfieldKey returns the key of a built-in ZField type
+func zfieldKey(field ZField) string {
+	switch f := field.(type) {
+	case StringZField:
+		return f.Key
+	case IntZField:
+		return f.Key
+	case Int64ZField:
+		return f.Key
+	case Float64ZField:
+		return f.Key
+	case BoolZField:
+		return f.Key
+	case TimeZField:
+		return f.Key
+	case DurationZField:
+		return f.Key
+	default:
+		return ""
+	}
+}
+
+// zfieldValue returns the raw value of a built-in ZField type
+func zfieldValue(field ZField) any {
+	switch f := field.(type) {
+	case StringZField:
+		return f.Value
+	case IntZField:
+		return f.Value
+	case Int64ZField:
+		return f.Value
+	case Float64ZField:
+		return f.Value
+	case BoolZField:
+		return f.Value
+	case TimeZField:
+		return f.Value
+	case DurationZField:
+		return f.Value
+	default:
+		return nil
+	}
+}
+
+// copyZField copies a built-in ZField's value into a new interface, so the
+// entry does not retain the caller's. Custom types are kept as they are.
+func copyZField(field ZField) ZField {
+	switch f := field.(type) {
+	case StringZField:
+		return f
+	case IntZField:
+		return f
+	case Int64ZField:
+		return f
+	case Float64ZField:
+		return f
+	case BoolZField:
+		return f
+	case TimeZField:
+		return f
+	case DurationZField:
+		return f
+	default:
+		return field
+	}
+}
+
+// toZField converts a hook-supplied value into a ZField the structured
+// fields writer understands
+func toZField(key string, value any) ZField {
+	switch v := value.(type) {
+	case string:
+		return ZString(key, v)
+	case int:
+		return ZInt(key, v)
+	case int64:
+		return ZInt64(key, v)
+	case int32:
+		return ZInt(key, int(v))
+	case float64:
+		return ZFloat64(key, v)
+	case float32:
+		return ZFloat64(key, float64(v))
+	case bool:
+		return ZBool(key, v)
+	case time.Time:
+		return ZTime(key, v)
+	case time.Duration:
+		return ZDuration(key, v)
+	case error:
+		return ZString(key, v.Error())
+	default:
+		return ZString(key, fmt.Sprint(v))
+	}
+}
+
+// maskZFieldValue applies the structured fields masking rules to a value
+func (l *Logger) maskZFieldValue(field ZField) any {
+	if field.IsSensitive() || field.IsPII() {
+		return zfieldMask
+	}
+	return zfieldValue(field)
+}
+
+// maskFieldValue applies the map-based masking rules to a single value
+func (l *Logger) maskFieldValue(key string, value any) any {
+	if l.isPIIFieldFast(key) {
+		return l.piiMaskString
+	}
+	if l.isSensitiveFieldFast(key) {
+		return l.maskString
+	}
+	if nested, ok := value.(map[string]any); ok {
+		return l.maskSensitiveFieldsFast(nested)
+	}
+	return value
+}
+
+// registeredHook is a hook with its ordering and level filter
+type registeredHook struct {
+	hook     Hook
+	priority int
+	levels   [ERROR + 1]bool
+}
+
+// hookChain holds the hooks of a logger, pre-sorted per level.
+// It is immutable; registration builds a new chain.
+type hookChain struct {
+	registered []registeredHook
+	byLevel    [ERROR + 1][]Hook
+}
+
+// with returns a new chain including hook
+func (c *hookChain) with(hook registeredHook) *hookChain {
+	next := &hookChain{}
+	if c != nil {
+		next.registered = slices.Clone(c.registered)
+	}
+	next.registered = append(next.registered, hook)
+
+	// Stable sort keeps registration order for equal priorities
+	slices.SortStableFunc(next.registered, func(a, b registeredHook) int {
+		return a.priority - b.priority
+	})
+
+	for _, registered := range next.registered {
+		for level := DEBUG; level <= ERROR; level++ {
+			if registered.levels[level] {
+				next.byLevel[level] = append(next.byLevel[level], registered.hook)
+			}
+		}
+	}
+	return next
+}
+
+// hooksFor returns the hooks registered for a level (nil if none)
+func (l *Logger) hooksFor(level LogLevel) []Hook {
+	if l.hooks == nil || level < DEBUG || level > ERROR {
+		return nil
+	}
+	return l.hooks.byLevel[level]
+}
+
+// fireHooks runs hooks in order, stopping at the first veto
+func fireHooks(hooks []Hook, entry *Entry) bool {
+	for _, hook := range hooks {
+		if !hook.Fire(entry) {
+			return false
+		}
+	}
+	return true
+}
+
+// runHooks applies hooks to a map-based entry, returning the rewritten
+// message and fields, or false if a hook vetoed it
+func (l *Logger) runHooks(level LogLevel, message string, fields map[string]any) (string, map[string]any, bool) {
+	hooks := l.hooksFor(level)
+	if len(hooks) == 0 {
+		return message, fields, true
+	}
+
+	entry := &Entry{logger: l, level: level, message: message, fields: fields}
+	if entry.fields == nil {
+		entry.fields, entry.owned = map[string]any{}, true
+	}
+	if !fireHooks(hooks, entry) {
+		l.dropped(level, DROP_HOOK)
+		return "", nil, false
+	}
+	return entry.message, entry.fields, true
+}
+
+// runStructuredHooks applies hooks to a structured fields entry, keeping
+// the fields as ZFields so the output shape is unchanged
+func (l *Logger) runStructuredHooks(level LogLevel, message string, fields []ZField) (string, []ZField, bool) {
+	hooks := l.hooksFor(level)
+	if len(hooks) == 0 {
+		return message, fields, true
+	}
+
+	// Copy the fields rather than retaining the caller's interfaces, so
+	// the variadic fields never escape on the hot path
+	entry := &Entry{logger: l, level: level, message: message, owned: true}
+	entry.zfields = make([]ZField, 0, len(fields)+2)
+	for _, field := range fields {
+		entry.zfields = append(entry.zfields, copyZField(field))
+	}
+
+	if !fireHooks(hooks, entry) {
+		l.dropped(level, DROP_HOOK)
+		return "", nil, false
+	}
+	return entry.message, entry.zfields, true
+}
+
+// AddHook registers a hook on the default logger for the given levels
+// (all levels if none are given). Hooks run in registration order.
+func AddHook(hook Hook, levels ...LogLevel) {
+	AddHookWithPriority(0, hook, levels...)
+}
+
+// AddHookWithPriority registers a hook that runs before hooks with a higher
+// priority value; equal priorities run in registration order
+func AddHookWithPriority(priority int, hook Hook, levels ...LogLevel) {
+	if defaultLogger == nil || hook == nil {
+		return
+	}
+
+	registered := registeredHook{hook: hook, priority: priority}
+	if len(levels) == 0 {
+		levels = []LogLevel{DEBUG, INFO, WARN, ERROR}
+	}
+	for _, level := range levels {
+		if level >= DEBUG && level <= ERROR {
+			registered.levels[level] = true
+		}
+	}
+
+	defaultLogger.hooks = defaultLogger.hooks.with(registered)
+}
+
+// ClearHooks removes all hooks from the default logger
+func ClearHooks() {
+	if defaultLogger != nil {
+		defaultLogger.hooks = nil
+	}
+}
@@ -0,0 +1,181 @@
+package emit
+
+import (
+	"bytes"
+	"io"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// allLevels registers a hook for every level
+func allLevels(hook Hook, priority int) registeredHook {
+	return registeredHook{hook: hook, priority: priority, levels: [ERROR + 1]bool{true, true, true, true}}
+}
+
+// TestHooksEnrichAndVeto tests mutation and veto on both API styles
+func TestHooksEnrichAndVeto(t *testing.T) {
+	var buf bytes.Buffer
+	testLogger := newTestLogger(&buf, withHooks(allLevels(HookFunc(func(entry *Entry) bool {
+		if entry.Message() == "noisy" {
+			return false
+		}
+		entry.SetField("region", "eu-west-1")
+		entry.DeleteField("internal")
+		entry.SetMessage(strings.ToUpper(entry.Message()))
+		return true
+	}), 0)))
+
+	callerFields := map[string]any{"internal": true, "id": 7}
+	testLogger.log(INFO, "map entry", callerFields)
+	testLogger.logStructuredFields(INFO, "structured entry", ZBool("internal", true), ZInt("id", 8))
+	testLogger.log(INFO, "simple entry", nil)
+	testLogger.log(INFO, "noisy", nil)
+	testLogger.logStructuredFields(WARN, "noisy")
+
+	output := buf.String()
+	for _, expected := range []string{
+		`"message":"MAP ENTRY"`,
+		`"fields":{"id":7,"region":"eu-west-1"}`,
+		`"message":"STRUCTURED ENTRY","id":8,"region":"eu-west-1"`,
+		`"message":"SIMPLE ENTRY"`,
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected %s in output:\n%s", expected, output)
+		}
+	}
+	if strings.Contains(output, "noisy") || strings.Contains(output, "internal") {
+		t.Errorf("Vetoed entry or deleted field found in output:\n%s", output)
+	}
+
+	// The caller's map is never modified
+	if _, ok := callerFields["region"]; ok || callerFields["internal"] != true {
+		t.Errorf("Hook mutated the caller's fields: %v", callerFields)
+	}
+}
+
+// TestHooksOrderAndLevels tests priority ordering and per-level registration
+func TestHooksOrderAndLevels(t *testing.T) {
+	var order []string
+	record := func(name string) Hook {
+		return HookFunc(func(entry *Entry) bool {
+			order = append(order, name+":"+entry.Level().String())
+			return true
+		})
+	}
+
+	var buf bytes.Buffer
+	testLogger := newTestLogger(&buf, withHooks(
+		allLevels(record("second"), 0),
+		registeredHook{hook: record("errors-only"), levels: [ERROR + 1]bool{ERROR: true}},
+		allLevels(record("first"), -10),
+	))
+
+	testLogger.log(INFO, "info", nil)
+	testLogger.logStructuredFields(ERROR, "error")
+
+	expected := "first:info second:info first:error second:error errors-only:error"
+	if got := strings.Join(order, " "); got != expected {
+		t.Errorf("Unexpected hook order:\n got: %s\nwant: %s", got, expected)
+	}
+}
+
+// TestHooksReadOnlyView tests masked field access and caller reporting
+func TestHooksReadOnlyView(t *testing.T) {
+	var seen map[string]any
+	var caller runtime.Frame
+
+	var buf bytes.Buffer
+	testLogger := newTestLogger(&buf, withHooks(allLevels(HookFunc(func(entry *Entry) bool {
+		seen = entry.Fields()
+		caller, _ = entry.Caller()
+		return true
+	}), 0)))
+
+	testLogger.log(ERROR, "login failed", map[string]any{"password": "hunter2", "email": "a@b.c", "attempt": 3})
+	_, _, line, _ := runtime.Caller(0)
+
+	if seen["password"] != "***MASKED***" || seen["email"] != "***PII***" || seen["attempt"] != 3 {
+		t.Errorf("Hook saw unmasked or wrong fields: %v", seen)
+	}
+	if !strings.HasSuffix(caller.File, "hooks_test.go") || caller.Line != line-1 {
+		t.Errorf("Expected caller hooks_test.go:%d, got %s:%d", line-1, caller.File, caller.Line)
+	}
+
+	testLogger.logStructuredFields(ERROR, "login failed", ZString("token", "abc"))
+	if seen["token"] != "***MASKED***" {
+		t.Errorf("Hook saw unmasked structured field: %v", seen)
+	}
+}
+
+// customZField is a ZField type defined outside the built-in set
+type customZField struct{ id int }
+
+func (customZField) WriteToEncoder(enc *ZeroAllocEncoder) {}
+func (customZField) IsSensitive() bool                    { return false }
+func (customZField) IsPII() bool                          { return false }
+
+// TestHooksKeepCustomZFields tests that custom ZField types survive hooks
+func TestHooksKeepCustomZFields(t *testing.T) {
+	var keys []string
+	testLogger := newTestLogger(io.Discard, withHooks(allLevels(HookFunc(func(entry *Entry) bool {
+		entry.Range(func(key string, value any) bool {
+			keys = append(keys, key)
+			return true
+		})
+		return true
+	}), 0)))
+
+	_, fields, ok := testLogger.runStructuredHooks(INFO, "request", []ZField{customZField{id: 7}, ZInt("n", 1)})
+	if !ok || len(fields) != 2 || fields[0] != (customZField{id: 7}) {
+		t.Errorf("Expected the custom field passed through, got %#v", fields)
+	}
+	if !slices.Equal(keys, []string{"n"}) {
+		t.Errorf("Expected hooks to list only keyed fields, got %q", keys)
+	}
+}
+
+// TestNoHooksNoAllocation tests that the structured path stays
+// allocation-free without hooks. The fields are built outside the measured
+// call: boxing them is the caller's allocation, as they escape into hooks.
+func TestNoHooksNoAllocation(t *testing.T) {
+	testLogger := newTestLogger(io.Discard, withLevel(INFO))
+
+	fields := []ZField{ZString("k", "v"), ZInt("n", 1)}
+	allocs := testing.AllocsPerRun(100, func() {
+		testLogger.logStructuredFields(INFO, "no hooks", fields...)
+	})
+	if allocs != 0 {
+		t.Errorf("Expected 0 allocations without hooks, got %v", allocs)
+	}
+}
+
+// TestHooksStructuredTypes tests that hooks see structured fields with
+// their types and the writer's mask, and that the types reach the output
+func TestHooksStructuredTypes(t *testing.T) {
+	var seen map[string]any
+	at := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	var buf bytes.Buffer
+	testLogger := newTestLogger(&buf, withHooks(allLevels(HookFunc(func(entry *Entry) bool {
+		seen = entry.Fields()
+		entry.SetField("checked_at", at)
+		return true
+	}), 0)))
+
+	testLogger.logStructuredFields(INFO, "request", ZTime("started", at), ZDuration("took", time.Second), ZInt64("bytes", 1<<40), ZString("email", "a@b.c"))
+
+	if seen["started"] != at || seen["took"] != time.Second || seen["bytes"] != int64(1<<40) {
+		t.Errorf("Hook saw converted field types: %#v", seen)
+	}
+	if seen["email"] != zfieldMask || !strings.Contains(buf.String(), `"email":"`+zfieldMask+`"`) {
+		t.Errorf("Expected the hook and the output to share the mask, got %v and %s", seen["email"], buf.String())
+	}
+	for _, expected := range []string{`"started":"2024-01-01T12:00:00Z"`, `"bytes":1099511627776`, `"checked_at":"2024-01-01T12:00:00Z"`} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("Expected %s in output: %s", expected, buf.String())
+		}
+	}
+}
@@ -0,0 +1,243 @@
+// Package httplog is net/http middleware writing one emit entry per
+// request, without dependencies outside the standard library.
+//
+//	mux := http.NewServeMux()
+//	mux.HandleFunc("GET /users/{id}", getUser)
+//	http.ListenAndServe(":8080", httplog.Middleware(httplog.Config{
+//		SkipPaths: []string{"/healthz"},
+//	})(mux))
+//
+// Each entry has the method, route, path, status, bytes, duration_ms,
+// remote_addr, user_agent and request_id fields, plus the query parameters
+// and any configured headers as nested objects. All of them go through the
+// logger's sensitive and PII rules, so ?token=... and the Authorization and
+// Cookie headers are masked, and user_agent is masked as PII unless PII is
+// shown (emit.ShowPIIData). Handlers log with the request's ID through
+// FromContext.
+package httplog
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cloudresty/emit"
+)
+
+// RequestIDHeader is the header carrying request IDs
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength is the longest incoming request ID kept
+const maxRequestIDLength = 128
+
+// Config configures the middleware
+type Config struct {
+	// StatusLevels sets the level of each status class, keyed by its first
+	// digit (5 for 5xx). Classes not listed keep the defaults: ERROR for
+	// 5xx, WARN for 4xx and INFO otherwise.
+	StatusLevels map[int]emit.LogLevel
+
+	// SkipPaths are paths logged without an entry (such as /healthz)
+	SkipPaths []string
+
+	// Headers are request headers added to entries (such as Authorization)
+	Headers []string
+
+	// Message is the entry message (default "HTTP request")
+	Message string
+}
+
+// defaultStatusLevels are the levels of status classes not configured
+var defaultStatusLevels = map[int]emit.LogLevel{5: emit.ERROR, 4: emit.WARN}
+
+// contextKey keys the request-scoped logger in request contexts
+type contextKey struct{}
+
+// Middleware returns middleware logging every request not skipped, including
+// requests whose handler panics (as 500s, before the panic continues).
+// Requests keep a valid X-Request-ID, or get a new one on the request passed
+// to the handler, and the ID is echoed in the response.
+func Middleware(config Config) func(http.Handler) http.Handler {
+	if config.Message == "" {
+		config.Message = "HTTP request"
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			requestID := r.Header.Get(RequestIDHeader)
+			if !validRequestID(requestID) {
+				requestID = newRequestID()
+			}
+			w.Header().Set(RequestIDHeader, requestID)
+
+			logger := emit.With("request_id", requestID)
+			r = r.WithContext(context.WithValue(r.Context(), contextKey{}, logger))
+			if r.Header.Get(RequestIDHeader) != requestID {
+				r.Header = r.Header.Clone()
+				r.Header.Set(RequestIDHeader, requestID)
+			}
+
+			if slices.Contains(config.SkipPaths, r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			rw := &responseWriter{ResponseWriter: w}
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					config.log(logger, r, rw, http.StatusInternalServerError, start)
+					panic(recovered)
+				}
+			}()
+			next.ServeHTTP(rw, r)
+			if rw.status == 0 {
+				rw.status = http.StatusOK
+			}
+			config.log(logger, r, rw, rw.status, start)
+		})
+	}
+}
+
+// log writes the entry of a request answered with status
+func (c Config) log(logger *emit.Child, r *http.Request, rw *responseWriter, status int, start time.Time) {
+	fields := []any{
+		"method", r.Method,
+		"route", route(r),
+		"path", r.URL.Path,
+		"status", status,
+		"bytes", rw.bytes,
+		"duration_ms", float64(time.Since(start).Microseconds()) / 1000,
+		"remote_addr", r.RemoteAddr,
+		"user_agent", r.UserAgent(),
+	}
+	if query := r.URL.Query(); len(query) > 0 {
+		fields = append(fields, "query", values(query))
+	}
+	if headers := c.headers(r.Header); len(headers) > 0 {
+		fields = append(fields, "headers", headers)
+	}
+	logger.Log(c.level(status), c.Message, fields...)
+}
+
+// FromContext returns the request-scoped logger, which adds the request ID
+// to entries, or a child of the default logger outside the middleware
+func FromContext(ctx context.Context) *emit.Child {
+	if logger, ok := ctx.Value(contextKey{}).(*emit.Child); ok {
+		return logger
+	}
+	return emit.With()
+}
+
+// level returns the level of a status
+func (c Config) level(status int) emit.LogLevel {
+	if level, ok := c.StatusLevels[status/100]; ok {
+		return level
+	}
+	if level, ok := defaultStatusLevels[status/100]; ok {
+		return level
+	}
+	return emit.INFO
+}
+
+// headers returns the configured headers present in a request, keyed by
+// their lowercase names so they match the masking rules
+func (c Config) headers(header http.Header) map[string]any {
+	var headers map[string]any
+	for _, name := range c.Headers {
+		if value := header.Values(name); len(value) > 0 {
+			if headers == nil {
+				headers = make(map[string]any, len(c.Headers))
+			}
+			headers[strings.ToLower(name)] = strings.Join(value, ", ")
+		}
+	}
+	return headers
+}
+
+// route returns the ServeMux pattern that matched, or the path
+func route(r *http.Request) string {
+	if r.Pattern != "" {
+		return r.Pattern
+	}
+	return r.URL.Path
+}
+
+// values flattens query parameters, joining repeated ones
+func values(query map[string][]string) map[string]any {
+	flat := make(map[string]any, len(query))
+	for key, value := range query {
+		flat[key] = strings.Join(value, ",")
+	}
+	return flat
+}
+
+// validRequestID reports whether an incoming request ID is short and made
+// of letters, digits, '-', '_', '.' and ':' only, so it is safe to log and
+// echo
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range []byte(id) {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID returns a random 128-bit hex request ID
+func newRequestID() string {
+	var id [16]byte
+	rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
+
+// responseWriter records the status and size of a response
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+// WriteHeader records the final (non-informational) status
+func (w *responseWriter) WriteHeader(status int) {
+	if w.status == 0 && status >= 200 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write records the bytes written
+func (w *responseWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.bytes += n
+	return n, err
+}
+
+// Flush flushes the response if the underlying writer supports it
+func (w *responseWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		flusher.Flush()
+	}
+}
+
+// Unwrap returns the underlying writer for http.ResponseController
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
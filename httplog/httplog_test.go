@@ -0,0 +1,213 @@
+package httplog
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cloudresty/emit"
+	"github.com/cloudresty/emit/emittest"
+)
+
+// captureEmit records the default logger's JSON entries for one test
+func captureEmit(t *testing.T) *emittest.Recorder {
+	emit.SetJSONFormat()
+	emit.SetLevel("debug")
+	return emittest.Capture(t, emit.GetOutput, emit.SetOutput)
+}
+
+// newTestServer serves a mux with the middleware applied
+func newTestServer(config Config) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		FromContext(r.Context()).Info("loading user", "user_id", r.PathValue("id"))
+		io.WriteString(w, "hello")
+	})
+	mux.HandleFunc("GET /missing", func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+	mux.HandleFunc("GET /fail", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	mux.HandleFunc("GET /panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("handler failed")
+	})
+	mux.HandleFunc("GET /echo-id", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.Header.Get(RequestIDHeader))
+	})
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {})
+	return Middleware(config)(mux)
+}
+
+// TestAccessEntry tests the fields of a request's entry and the request-scoped logger
+func TestAccessEntry(t *testing.T) {
+	logs := captureEmit(t)
+	handler := newTestServer(Config{Headers: []string{"Authorization", "Cookie", "Accept"}})
+
+	req := httptest.NewRequest("GET", "/users/42?page=2&token=abc123", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("Cookie", "session=xyz")
+	req.Header.Set("Accept", "text/plain")
+	req.Header.Set("User-Agent", "curl/8.5.0")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	requestID := rec.Header().Get(RequestIDHeader)
+	if len(requestID) != 32 {
+		t.Fatalf("Expected a generated request ID, got %q", requestID)
+	}
+
+	entries := logs.Entries(t)
+	if len(entries) != 2 {
+		t.Fatalf("Expected the handler's and the access entry, got %q", logs.String())
+	}
+	if entries[0].Message != "loading user" || entries[0].Fields["request_id"] != requestID {
+		t.Errorf("Expected the handler entry with the request ID, got %q", logs.String())
+	}
+
+	access := entries[1]
+	want := map[string]any{
+		"method":      "GET",
+		"route":       "GET /users/{id}",
+		"path":        "/users/42",
+		"status":      float64(200),
+		"bytes":       float64(5),
+		"remote_addr": "192.0.2.1:1234",
+		"user_agent":  "***PII***",
+		"request_id":  requestID,
+	}
+	for key, value := range want {
+		if access.Fields[key] != value {
+			t.Errorf("Expected %s=%v, got %v", key, value, access.Fields[key])
+		}
+	}
+	if _, ok := access.Fields["duration_ms"].(float64); !ok || access.Level != "info" {
+		t.Errorf("Expected an info entry with duration_ms, got %q", logs.String())
+	}
+
+	query, _ := access.Fields["query"].(map[string]any)
+	if query["page"] != "2" || query["token"] != "***MASKED***" {
+		t.Errorf("Expected the token parameter masked, got %v", query)
+	}
+	headers, _ := access.Fields["headers"].(map[string]any)
+	if headers["authorization"] != "***MASKED***" || headers["cookie"] != "***MASKED***" || headers["accept"] != "text/plain" {
+		t.Errorf("Expected Authorization and Cookie masked, got %v", headers)
+	}
+}
+
+// TestUserAgentShownWithPII tests that user_agent follows the PII mode
+func TestUserAgentShownWithPII(t *testing.T) {
+	logs := captureEmit(t)
+	emit.ShowPIIData()
+	t.Cleanup(emit.MaskPIIData)
+	handler := newTestServer(Config{})
+
+	req := httptest.NewRequest("GET", "/users/42", nil)
+	req.Header.Set("User-Agent", "curl/8.5.0")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	entries := logs.Entries(t)
+	if len(entries) != 2 || entries[1].Fields["user_agent"] != "curl/8.5.0" {
+		t.Errorf("Expected the user agent readable with PII shown, got %q", logs.String())
+	}
+}
+
+// TestRequestIDPropagation tests that incoming request IDs are kept
+func TestRequestIDPropagation(t *testing.T) {
+	logs := captureEmit(t)
+	handler := newTestServer(Config{})
+
+	req := httptest.NewRequest("GET", "/users/7", nil)
+	req.Header.Set(RequestIDHeader, "upstream-id")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get(RequestIDHeader); got != "upstream-id" {
+		t.Errorf("Expected the incoming request ID echoed, got %q", got)
+	}
+	for _, e := range logs.Entries(t) {
+		if e.Fields["request_id"] != "upstream-id" {
+			t.Errorf("Expected the incoming request ID on every entry, got %q", logs.String())
+		}
+	}
+}
+
+// TestStatusLevelsAndSkipPaths tests levels by status class and skipped paths
+func TestStatusLevelsAndSkipPaths(t *testing.T) {
+	logs := captureEmit(t)
+	handler := newTestServer(Config{
+		StatusLevels: map[int]emit.LogLevel{4: emit.INFO},
+		SkipPaths:    []string{"/healthz"},
+	})
+
+	for _, path := range []string{"/healthz", "/missing", "/fail"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	entries := logs.Entries(t)
+	if len(entries) != 2 {
+		t.Fatalf("Expected entries for /missing and /fail only, got %q", logs.String())
+	}
+	if entries[0].Level != "info" || entries[0].Fields["status"] != float64(404) {
+		t.Errorf("Expected 404 at the configured info level, got %q", logs.String())
+	}
+	if entries[1].Level != "error" || entries[1].Fields["status"] != float64(500) {
+		t.Errorf("Expected 500 at the default error level, got %q", logs.String())
+	}
+}
+
+// TestFromContextOutsideMiddleware tests the fallback logger
+func TestFromContextOutsideMiddleware(t *testing.T) {
+	logs := captureEmit(t)
+	FromContext(httptest.NewRequest("GET", "/", nil).Context()).Info("no request")
+
+	entries := logs.Entries(t)
+	if len(entries) != 1 || entries[0].Fields != nil {
+		t.Errorf("Expected a plain entry, got %q", logs.String())
+	}
+}
+
+// TestInvalidRequestID tests that unsafe incoming request IDs are replaced
+// on the derived request only
+func TestInvalidRequestID(t *testing.T) {
+	captureEmit(t)
+	handler := newTestServer(Config{})
+
+	for _, id := range []string{"bad id\n", strings.Repeat("a", 129)} {
+		req := httptest.NewRequest("GET", "/echo-id", nil)
+		req.Header.Set(RequestIDHeader, id)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		got := rec.Header().Get(RequestIDHeader)
+		if len(got) != 32 || rec.Body.String() != got {
+			t.Errorf("Expected a new request ID passed to the handler for %q, got %q and %q", id, got, rec.Body.String())
+		}
+		if req.Header.Get(RequestIDHeader) != id {
+			t.Errorf("Expected the caller's header left unchanged, got %q", req.Header.Get(RequestIDHeader))
+		}
+	}
+}
+
+// TestPanicEntry tests that a panicking handler is logged as a 500 and
+// the panic continues
+func TestPanicEntry(t *testing.T) {
+	logs := captureEmit(t)
+	handler := newTestServer(Config{})
+
+	func() {
+		defer func() {
+			if recovered := recover(); recovered != "handler failed" {
+				t.Errorf("Expected the handler's panic to continue, got %v", recovered)
+			}
+		}()
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/panic", nil))
+	}()
+
+	entries := logs.Entries(t)
+	if len(entries) != 1 || entries[0].Level != "error" || entries[0].Fields["status"] != float64(500) {
+		t.Errorf("Expected a 500 error entry, got %q", logs.String())
+	}
+}
@@ -26,7 +26,19 @@ func init() {
 
 // log writes a log entry at the specified level
 func (l *Logger) log(level LogLevel, message string, fields map[string]any) {
-	if level < l.level {
+	if level < l.level || !l.admit(level, message) {
+		return
+	}
+
+	if l.hooks != nil {
+		var ok bool
+		if message, fields, ok = l.runHooks(level, message, fields); !ok {
+			return
+		}
+	}
+
+	if layout := l.formatLayout(); layout != nil {
+		l.encodeEntry(layout, level, message, fields, nil)
 		return
 	}
 
@@ -45,8 +57,51 @@ func (l *Logger) log(level LogLevel, message string, fields map[string]any) {
 	}
 }
 
+// admit runs an entry through sampling, rate limiting and deduplication.
+// Only entries the limiter accepts start or extend duplicate runs. Every
+// entry point calls it once, after the level check.
+func (l *Logger) admit(level LogLevel, message string) bool {
+	if l.limiter == nil {
+		if l.sample(level, message) {
+			return true
+		}
+		l.dropped(level, DROP_SAMPLED)
+		return false
+	}
+
+	switch {
+	case !l.sample(level, message):
+		l.dropped(level, DROP_SAMPLED)
+	case !l.limiter.allow(level):
+		l.dropped(level, DROP_RATE_LIMITED)
+	case l.limiter.duplicate(level, message):
+		l.dropped(level, DROP_DEDUPLICATED)
+	default:
+		return true
+	}
+	return false
+}
+
 // logSimpleUltraFast - Specialized simple message logger with dynamic buffer
 func (l *Logger) logSimpleUltraFast(level LogLevel, message string) {
+	if l.profile != nil && l.format == JSON_FORMAT {
+		l.encodeEntry(l.profile, level, message, nil, nil)
+		return
+	}
+
+	// The prebuilt simple entries have no room for a caller, stack trace
+	// or goroutine ID
+	if l.showCaller || l.wantsStack(level) || l.enrichment != nil && l.enrichment.goroutine {
+		if l.format == JSON_FORMAT {
+			l.logJSON(level, message, nil)
+		} else {
+			l.logPlain(level, message, nil)
+		}
+		return
+	}
+
+	start := l.encodeStart()
+
 	// Start with small optimal stack buffer for most common cases
 	var stackBuf [128]byte
 	var pos int
@@ -89,7 +144,7 @@ func (l *Logger) logSimpleUltraFast(level LogLevel, message string) {
 	}
 
 	// Single write operation - most critical optimization
-	_, _ = l.writer.Write(buf[:pos])
+	l.write(level, buf[:pos], start)
 }
 
 // InfoStructured logs at INFO level with structured fields optimization
@@ -133,8 +188,8 @@ func (l *Logger) estimateJSONSize(level LogLevel, message string) int {
 	// Base JSON structure: {"timestamp":"","level":"","message":""}
 	baseSize := 50
 
-	// Timestamp: ISO 8601 format is ~24 characters
-	timestampSize := 30
+	// Timestamp: the longest the time encoder writes, plus quotes
+	timestampSize := l.times().maxLen + 2
 
 	// Level: debug/info/warn/error (max ~5 chars)
 	levelSize := 10
@@ -161,8 +216,8 @@ func (l *Logger) estimateJSONSize(level LogLevel, message string) int {
 
 // estimatePlainSize calculates the approximate size needed for plain text output
 func (l *Logger) estimatePlainSize(level LogLevel, message string) int {
-	// Timestamp: 19 characters (YYYY-MM-DD HH:MM:SS)
-	timestampSize := 25
+	// Timestamp: the longest the time encoder writes
+	timestampSize := l.times().maxLen
 
 	// Separators: " | " + " | " + ": " + "\n" = ~10 chars
 	separatorSize := 15
@@ -0,0 +1,10 @@
+module github.com/cloudresty/emit/logremit
+
+go 1.24
+
+require (
+	github.com/cloudresty/emit v1.2.0
+	github.com/go-logr/logr v1.4.3
+)
+
+replace github.com/cloudresty/emit => ../
@@ -0,0 +1,108 @@
+// Package logremit is a logr.LogSink writing through emit, for code logging
+// with go-logr/logr such as Kubernetes controllers built on
+// controller-runtime.
+//
+//	ctrl.SetLogger(logremit.New())
+//
+// V(0) entries are written at INFO and V(1) and above at DEBUG with their
+// verbosity in the v field. WithName extends the component ("operator"
+// becomes "operator.reconciler"), WithValues derives a child logger, and
+// Error adds the error's message as the error field. Every field is masked
+// by emit like any other.
+//
+// Call depth added with logr's WithCallDepth or WithCallStackHelper is
+// honoured, so helpers wrapping a logr.Logger report their own callers.
+package logremit
+
+import (
+	"slices"
+
+	"github.com/cloudresty/emit"
+	"github.com/go-logr/logr"
+)
+
+// LogSink is a logr.LogSink writing to emit's default logger
+type LogSink struct {
+	child *emit.Child
+}
+
+var _ logr.CallDepthLogSink = (*LogSink)(nil)
+
+// New returns a logr.Logger writing through emit
+func New() logr.Logger {
+	return logr.New(NewLogSink())
+}
+
+// NewLogSink returns a sink writing through emit
+func NewLogSink() *LogSink {
+	return &LogSink{child: emit.With()}
+}
+
+// Init skips logr's frames when emit reports callers
+func (s *LogSink) Init(info logr.RuntimeInfo) {
+	s.child = s.child.AddCallerSkip(info.CallDepth + 1)
+}
+
+// Enabled reports whether emit writes entries of a verbosity
+func (s *LogSink) Enabled(level int) bool {
+	return s.child.Enabled(emitLevel(level))
+}
+
+// Info logs a non-error entry at the emit level of its verbosity
+func (s *LogSink) Info(level int, message string, keysAndValues ...any) {
+	keysAndValues = errorStrings(keysAndValues)
+	if level > 0 {
+		keysAndValues = append(keysAndValues[:len(keysAndValues):len(keysAndValues)], "v", level)
+	}
+	s.child.Log(emitLevel(level), message, keysAndValues...)
+}
+
+// Error logs an ERROR entry with the error's message as the error field
+func (s *LogSink) Error(err error, message string, keysAndValues ...any) {
+	keysAndValues = errorStrings(keysAndValues)
+	if err != nil {
+		keysAndValues = append(keysAndValues[:len(keysAndValues):len(keysAndValues)], "error", err.Error())
+	}
+	s.child.Log(emit.ERROR, message, keysAndValues...)
+}
+
+// WithValues returns a sink adding key-value pairs to every entry
+func (s *LogSink) WithValues(keysAndValues ...any) logr.LogSink {
+	return &LogSink{child: s.child.With(errorStrings(keysAndValues)...)}
+}
+
+// WithName returns a sink whose component has name appended
+func (s *LogSink) WithName(name string) logr.LogSink {
+	return &LogSink{child: s.child.Named(name)}
+}
+
+// WithCallDepth returns a sink reporting callers depth frames further up
+func (s *LogSink) WithCallDepth(depth int) logr.LogSink {
+	return &LogSink{child: s.child.AddCallerSkip(depth)}
+}
+
+// emitLevel maps a logr verbosity to an emit level
+func emitLevel(level int) emit.LogLevel {
+	if level > 0 {
+		return emit.DEBUG
+	}
+	return emit.INFO
+}
+
+// errorStrings returns the pairs with error values replaced by their
+// messages, copying them only if there is one
+func errorStrings(keysAndValues []any) []any {
+	var copied []any
+	for i := 1; i < len(keysAndValues); i += 2 {
+		if err, ok := keysAndValues[i].(error); ok {
+			if copied == nil {
+				copied = slices.Clone(keysAndValues)
+			}
+			copied[i] = err.Error()
+		}
+	}
+	if copied == nil {
+		return keysAndValues
+	}
+	return copied
+}
@@ -0,0 +1,106 @@
+package logremit
+
+import (
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/cloudresty/emit"
+	"github.com/cloudresty/emit/emittest"
+)
+
+// captureEmit records the default logger's JSON entries for one test
+func captureEmit(t *testing.T, level string) *emittest.Recorder {
+	emit.SetJSONFormat()
+	emit.SetLevel(level)
+	emit.SetComponent("operator")
+	t.Cleanup(func() {
+		emit.SetComponent("")
+		emit.SetShowCaller(false)
+	})
+	return emittest.Capture(t, emit.GetOutput, emit.SetOutput)
+}
+
+// TestNamesValuesAndMasking tests WithName, WithValues and masking
+func TestNamesValuesAndMasking(t *testing.T) {
+	rec := captureEmit(t, "debug")
+	logger := New().WithName("reconciler").WithValues("cluster", "prod", "token", "abc123")
+
+	logger.Info("reconciled", "replicas", 3)
+	logger.WithName("pods").Info("scaled")
+
+	entries := rec.Entries(t)
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %q", rec.String())
+	}
+	if entries[0].Component != "operator.reconciler" || entries[1].Component != "operator.reconciler.pods" {
+		t.Errorf("Expected the component hierarchy, got %q", rec.String())
+	}
+	fields := entries[0].Fields
+	if fields["cluster"] != "prod" || fields["token"] != "***MASKED***" || fields["replicas"] != float64(3) {
+		t.Errorf("Expected the values, masked, with the entry's own, got %v", fields)
+	}
+}
+
+// TestVerbosity tests the mapping of V levels to emit levels
+func TestVerbosity(t *testing.T) {
+	rec := captureEmit(t, "info")
+	logger := New()
+
+	if !logger.Enabled() || logger.V(1).Enabled() {
+		t.Errorf("Expected V(0) enabled and V(1) disabled at INFO")
+	}
+	logger.V(1).Info("filtered")
+	emit.SetLevel("debug")
+	logger.V(0).Info("info")
+	logger.V(2).Info("debug")
+
+	entries := rec.Entries(t)
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %q", rec.String())
+	}
+	if entries[0].Level != "info" || entries[0].Fields["v"] != nil {
+		t.Errorf("Expected V(0) at info without v, got %q", rec.String())
+	}
+	if entries[1].Level != "debug" || entries[1].Fields["v"] != float64(2) {
+		t.Errorf("Expected V(2) at debug with v=2, got %q", rec.String())
+	}
+}
+
+// TestError tests the error field of Error and of error values
+func TestError(t *testing.T) {
+	rec := captureEmit(t, "info")
+	logger := New()
+
+	logger.Error(errors.New("conflict"), "update failed", "cause", errors.New("stale"))
+	logger.Error(nil, "no error value")
+
+	entries := rec.Entries(t)
+	if len(entries) != 2 || entries[0].Level != "error" {
+		t.Fatalf("Expected 2 error entries, got %q", rec.String())
+	}
+	if entries[0].Fields["error"] != "conflict" || entries[0].Fields["cause"] != "stale" {
+		t.Errorf("Expected error messages as fields, got %v", entries[0].Fields)
+	}
+	if _, ok := entries[1].Fields["error"]; ok {
+		t.Errorf("Expected no error field for a nil error, got %v", entries[1].Fields)
+	}
+}
+
+// TestCaller tests that callers of the logr.Logger are reported
+func TestCaller(t *testing.T) {
+	rec := captureEmit(t, "info")
+	emit.SetShowCaller(true)
+	logger := New().WithValues("k", "v")
+
+	_, _, line, _ := runtime.Caller(0)
+	logger.Info("called")
+	logger.WithCallDepth(0).Error(nil, "called too")
+
+	for i, e := range rec.Entries(t) {
+		if !strings.HasSuffix(e.File, "logremit_test.go") || e.Line != line+1+i {
+			t.Errorf("Expected logremit_test.go:%d, got %s:%d", line+1+i, e.File, e.Line)
+		}
+	}
+}
@@ -0,0 +1,12 @@
+module github.com/cloudresty/emit/logrusemit
+
+go 1.24
+
+require (
+	github.com/cloudresty/emit v1.2.0
+	github.com/sirupsen/logrus v1.9.3
+)
+
+require golang.org/x/sys v0.0.0-20220715151400-c0bba94af5f8 // indirect
+
+replace github.com/cloudresty/emit => ../
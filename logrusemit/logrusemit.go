@@ -0,0 +1,109 @@
+// Package logrusemit writes logrus entries through emit, so existing logrus
+// call sites get emit's output formats and masking without being rewritten.
+//
+// Either install the formatter, which writes each entry through emit and
+// leaves nothing for logrus to write:
+//
+//	logrus.SetFormatter(logrusemit.Formatter{})
+//
+// or add the hook and discard logrus' own output:
+//
+//	logrus.AddHook(logrusemit.Hook{})
+//	logrus.SetOutput(io.Discard)
+//
+// Logrus still filters by its own level first. With emit.SetShowCaller the
+// reported caller is the logrus call site, not the adapter.
+package logrusemit
+
+import (
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/cloudresty/emit"
+	"github.com/sirupsen/logrus"
+)
+
+// Hook is a logrus.Hook writing every entry to emit's default logger
+type Hook struct{}
+
+// Levels returns all levels, leaving the filtering to logrus and emit
+func (Hook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire writes an entry through emit
+func (Hook) Fire(entry *logrus.Entry) error {
+	write(entry)
+	return nil
+}
+
+// Formatter is a logrus.Formatter writing every entry to emit's default
+// logger instead of formatting it
+type Formatter struct{}
+
+// Format writes an entry through emit and returns nothing for logrus to write
+func (Formatter) Format(entry *logrus.Entry) ([]byte, error) {
+	write(entry)
+	return nil, nil
+}
+
+// write logs an entry at its emit level. Error values become their
+// messages, and logrus' caller (with ReportCaller) the caller field.
+func write(entry *logrus.Entry) {
+	fields := make(emit.Fields, len(entry.Data)+1)
+	for key, value := range entry.Data {
+		if err, ok := value.(error); ok {
+			value = err.Error()
+		}
+		fields[key] = value
+	}
+	if entry.Caller != nil {
+		fields["caller"] = entry.Caller.File + ":" + strconv.Itoa(entry.Caller.Line)
+	}
+
+	emit.With().AddCallerSkip(callerSkip()).LogFields(emitLevel(entry.Level), entry.Message, fields)
+}
+
+// emitLevel maps a logrus level to an emit level
+func emitLevel(level logrus.Level) emit.LogLevel {
+	switch level {
+	case logrus.TraceLevel, logrus.DebugLevel:
+		return emit.DEBUG
+	case logrus.InfoLevel:
+		return emit.INFO
+	case logrus.WarnLevel:
+		return emit.WARN
+	default:
+		return emit.ERROR
+	}
+}
+
+// callerSkip counts the adapter and logrus frames between write and the
+// code that logged
+func callerSkip() int {
+	var pcs [64]uintptr
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs[:])])
+
+	skip := 0
+	for {
+		frame, more := frames.Next()
+		if !isLogrusFrame(frame) {
+			return skip
+		}
+		if !more {
+			return 0
+		}
+		skip++
+	}
+}
+
+// isLogrusFrame reports whether a frame belongs to logrus or this adapter
+// (but not its tests)
+func isLogrusFrame(frame runtime.Frame) bool {
+	if strings.HasSuffix(frame.File, "_test.go") {
+		return false
+	}
+	return strings.HasPrefix(frame.Function, "github.com/sirupsen/logrus.") ||
+		strings.HasPrefix(frame.Function, "github.com/cloudresty/emit/logrusemit.")
+}
@@ -0,0 +1,118 @@
+package logrusemit
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/cloudresty/emit"
+	"github.com/cloudresty/emit/emittest"
+	"github.com/sirupsen/logrus"
+)
+
+// captureEmit records the default logger's JSON entries for one test
+func captureEmit(t *testing.T) *emittest.Recorder {
+	emit.SetJSONFormat()
+	emit.SetLevel("debug")
+	return emittest.Capture(t, emit.GetOutput, emit.SetOutput)
+}
+
+// TestFormatter tests that the formatter writes masked entries through emit only
+func TestFormatter(t *testing.T) {
+	rec := captureEmit(t)
+	var logrusOutput bytes.Buffer
+	logger := logrus.New()
+	logger.SetOutput(&logrusOutput)
+	logger.SetFormatter(Formatter{})
+
+	logger.WithFields(logrus.Fields{"user": "alice", "password": "hunter2"}).
+		WithError(errors.New("bad password")).
+		Warn("login failed")
+
+	entries := rec.Entries(t)
+	if len(entries) != 1 || entries[0].Level != "warn" || entries[0].Message != "login failed" {
+		t.Fatalf("Expected one warn entry, got %q", rec.String())
+	}
+	want := map[string]any{"user": "alice", "password": "***MASKED***", "error": "bad password"}
+	for key, value := range want {
+		if entries[0].Fields[key] != value {
+			t.Errorf("Expected %s=%v, got %v in %q", key, value, entries[0].Fields[key], rec.String())
+		}
+	}
+	if logrusOutput.Len() != 0 {
+		t.Errorf("Expected nothing written by logrus, got %q", logrusOutput.String())
+	}
+}
+
+// TestHook tests the hook and the level mapping
+func TestHook(t *testing.T) {
+	rec := captureEmit(t)
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	logger.SetLevel(logrus.TraceLevel)
+	logger.AddHook(Hook{})
+
+	logger.Trace("trace")
+	logger.Debug("debug")
+	logger.Info("info")
+	logger.Warn("warn")
+	logger.Error("error")
+
+	entries := rec.Entries(t)
+	levels := []string{"debug", "debug", "info", "warn", "error"}
+	if len(entries) != len(levels) {
+		t.Fatalf("Expected %d entries, got %q", len(levels), rec.String())
+	}
+	for i, level := range levels {
+		if entries[i].Level != level {
+			t.Errorf("Entry %d: expected level %s, got %s", i, level, entries[i].Level)
+		}
+	}
+}
+
+// TestCaller tests that logrus' reported caller becomes the caller field
+func TestCaller(t *testing.T) {
+	rec := captureEmit(t)
+	logger := logrus.New()
+	logger.SetReportCaller(true)
+	logger.SetFormatter(Formatter{})
+
+	logger.Info("with caller")
+
+	entries := rec.Entries(t)
+	caller, _ := entries[0].Fields["caller"].(string)
+	if !strings.Contains(caller, "logrusemit_test.go:") {
+		t.Errorf("Expected the test file as caller, got %q", rec.String())
+	}
+}
+
+// TestEmitCaller tests that emit reports the logrus call site through
+// the formatter and the hook
+func TestEmitCaller(t *testing.T) {
+	rec := captureEmit(t)
+	emit.SetShowCaller(true)
+	t.Cleanup(func() { emit.SetShowCaller(false) })
+
+	formatted := logrus.New()
+	formatted.SetFormatter(Formatter{})
+	hooked := logrus.New()
+	hooked.SetOutput(io.Discard)
+	hooked.AddHook(Hook{})
+	_, _, line, _ := runtime.Caller(0)
+	formatted.Info("formatter")
+	hooked.WithField("k", "v").Warn("hook")
+
+	entries := rec.Entries(t)
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %q", rec.String())
+	}
+	for i, e := range entries {
+		if filepath.Base(e.File) != "logrusemit_test.go" || e.Line != line+1+i {
+			t.Errorf("%s: expected logrusemit_test.go:%d, got %s:%d", e.Message, line+1+i, e.File, e.Line)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package emit
+
+import "time"
+
+// DropReason identifies why an admitted-level entry was not written
+type DropReason int
+
+const (
+	DROP_SAMPLED      DropReason = iota // Dropped by the sampler
+	DROP_DEDUPLICATED                   // Collapsed into a "repeated" entry
+	DROP_RATE_LIMITED                   // Dropped by a token bucket
+	DROP_HOOK                           // Vetoed by a hook
+)
+
+// String returns the reason as used in metric labels
+func (r DropReason) String() string {
+	switch r {
+	case DROP_SAMPLED:
+		return "sampled"
+	case DROP_DEDUPLICATED:
+		return "deduplicated"
+	case DROP_RATE_LIMITED:
+		return "rate_limited"
+	case DROP_HOOK:
+		return "hook"
+	default:
+		return "unknown"
+	}
+}
+
+// Metrics receives counters about the logger itself. Implementations are
+// called on the logging goroutine, must be safe for concurrent use and
+// should not block. The emit/metrics package provides one with a
+// Prometheus handler.
+type Metrics interface {
+	// EntryWritten is called after an entry was written successfully
+	EntryWritten(level LogLevel, component string, bytes int, encode time.Duration)
+
+	// WriteError is called when the writer returned an error
+	WriteError(level LogLevel, component string, err error)
+
+	// EntryDropped is called when an entry passed the level check but was not written
+	EntryDropped(level LogLevel, component string, reason DropReason)
+
+	// FieldsMasked is called with the number of fields masked in an entry
+	FieldsMasked(level LogLevel, component string, count int)
+}
+
+// encodeStart returns the time encoding started, or the zero time when no
+// metrics are collected (avoiding the clock read on the hot path)
+func (l *Logger) encodeStart() time.Time {
+	if l.metrics == nil {
+		return time.Time{}
+	}
+	return time.Now()
+}
+
+// write sends an encoded entry to the writer and reports the outcome
+func (l *Logger) write(level LogLevel, p []byte, start time.Time) {
+	var n int
+	var err error
+	if l.writeErrors == nil {
+		n, err = l.writer.Write(p)
+	} else {
+		n, err = l.writeErrors.write(l.clockOrSystem(), l.writer, p)
+	}
+
+	if l.metrics == nil {
+		return
+	}
+
+	if err != nil {
+		l.metrics.WriteError(level, l.component, err)
+		return
+	}
+	l.metrics.EntryWritten(level, l.component, n, time.Since(start))
+}
+
+// dropped reports an entry dropped after the level check
+func (l *Logger) dropped(level LogLevel, reason DropReason) {
+	if l.metrics != nil {
+		l.metrics.EntryDropped(level, l.component, reason)
+	}
+}
+
+// fieldsMasked reports the number of masked fields in an entry
+func (l *Logger) fieldsMasked(level LogLevel, count int) {
+	if l.metrics != nil && count > 0 {
+		l.metrics.FieldsMasked(level, l.component, count)
+	}
+}
+
+// countMaskedFields counts the map fields (including nested maps) that
+// masking replaces
+func (l *Logger) countMaskedFields(fields map[string]any) int {
+	count := 0
+	for key, value := range fields {
+		if l.isPIIFieldFast(key) || l.isSensitiveFieldFast(key) {
+			count++
+		} else if nested, ok := value.(map[string]any); ok {
+			count += l.countMaskedFields(nested)
+		}
+	}
+	return count
+}
+
+// SetMetrics reports the default logger's own metrics to m (nil disables them)
+func SetMetrics(m Metrics) {
+	if defaultLogger != nil {
+		defaultLogger.metrics = m
+	}
+}
@@ -0,0 +1,211 @@
+// Package metrics collects emit's own metrics and exposes them in the
+// Prometheus text exposition format, without external dependencies.
+//
+//	collector := metrics.New()
+//	emit.SetMetrics(collector)
+//	http.Handle("/metrics", collector.Handler())
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cloudresty/emit"
+)
+
+// encodeBuckets are the upper bounds (in seconds) of the encode latency histogram
+var encodeBuckets = [...]float64{
+	0.000001, 0.0000025, 0.000005, 0.00001, 0.000025, 0.00005,
+	0.0001, 0.00025, 0.0005, 0.001, 0.0025, 0.005, 0.01,
+}
+
+// Number of drop reasons defined by emit
+const dropReasons = int(emit.DROP_HOOK) + 1
+
+// seriesKey identifies the counters of one level and component
+type seriesKey struct {
+	level     emit.LogLevel
+	component string
+}
+
+// series holds the counters of one level and component
+type series struct {
+	entries     atomic.Uint64
+	bytes       atomic.Uint64
+	writeErrors atomic.Uint64
+	masked      atomic.Uint64
+	dropped     [dropReasons]atomic.Uint64
+}
+
+// Collector implements emit.Metrics and serves the collected values
+type Collector struct {
+	mu     sync.RWMutex
+	series map[seriesKey]*series
+
+	// Encode latency histogram (non-cumulative bucket counts, last is +Inf)
+	buckets  [len(encodeBuckets) + 1]atomic.Uint64
+	count    atomic.Uint64
+	sumNanos atomic.Uint64
+}
+
+// New returns an empty collector
+func New() *Collector {
+	return &Collector{series: make(map[seriesKey]*series)}
+}
+
+// get returns the series for a level and component, creating it if needed
+func (c *Collector) get(level emit.LogLevel, component string) *series {
+	key := seriesKey{level: level, component: component}
+
+	c.mu.RLock()
+	s, ok := c.series[key]
+	c.mu.RUnlock()
+	if ok {
+		return s
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if s, ok = c.series[key]; !ok {
+		s = &series{}
+		c.series[key] = s
+	}
+	return s
+}
+
+// EntryWritten counts a written entry, its size and encode latency
+func (c *Collector) EntryWritten(level emit.LogLevel, component string, bytes int, encode time.Duration) {
+	s := c.get(level, component)
+	s.entries.Add(1)
+	s.bytes.Add(uint64(bytes))
+
+	seconds := encode.Seconds()
+	bucket := len(encodeBuckets)
+	for i, bound := range encodeBuckets {
+		if seconds <= bound {
+			bucket = i
+			break
+		}
+	}
+	c.buckets[bucket].Add(1)
+	c.count.Add(1)
+	c.sumNanos.Add(uint64(max(encode, 0)))
+}
+
+// WriteError counts a failed write
+func (c *Collector) WriteError(level emit.LogLevel, component string, err error) {
+	c.get(level, component).writeErrors.Add(1)
+}
+
+// EntryDropped counts an entry dropped by sampling, rate limiting or a hook
+func (c *Collector) EntryDropped(level emit.LogLevel, component string, reason emit.DropReason) {
+	if reason >= 0 && int(reason) < dropReasons {
+		c.get(level, component).dropped[reason].Add(1)
+	}
+}
+
+// FieldsMasked counts masked fields
+func (c *Collector) FieldsMasked(level emit.LogLevel, component string, count int) {
+	c.get(level, component).masked.Add(uint64(count))
+}
+
+// Handler serves the metrics in the Prometheus text format
+func (c *Collector) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_, _ = c.WriteTo(w)
+	})
+}
+
+// WriteTo writes the metrics in the Prometheus text format
+func (c *Collector) WriteTo(w io.Writer) (int64, error) {
+	c.mu.RLock()
+	keys := make([]seriesKey, 0, len(c.series))
+	all := make(map[seriesKey]*series, len(c.series))
+	for key, s := range c.series {
+		keys = append(keys, key)
+		all[key] = s
+	}
+	c.mu.RUnlock()
+
+	// Stable output: by component, then level
+	slices.SortFunc(keys, func(a, b seriesKey) int {
+		if n := strings.Compare(a.component, b.component); n != 0 {
+			return n
+		}
+		return int(a.level) - int(b.level)
+	})
+
+	cw := &countingWriter{w: bufio.NewWriter(w)}
+
+	counter := func(name, help string, value func(s *series) uint64) {
+		fmt.Fprintf(cw, "# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+		for _, key := range keys {
+			fmt.Fprintf(cw, "%s{%s} %d\n", name, labels(key), value(all[key]))
+		}
+	}
+
+	counter("emit_entries_total", "Log entries written.", func(s *series) uint64 { return s.entries.Load() })
+	counter("emit_bytes_written_total", "Bytes of encoded log entries written.", func(s *series) uint64 { return s.bytes.Load() })
+	counter("emit_write_errors_total", "Log entries the writer failed to write.", func(s *series) uint64 { return s.writeErrors.Load() })
+	counter("emit_masked_fields_total", "Fields masked as sensitive or PII.", func(s *series) uint64 { return s.masked.Load() })
+
+	fmt.Fprint(cw, "# HELP emit_dropped_entries_total Log entries dropped after the level check.\n# TYPE emit_dropped_entries_total counter\n")
+	for _, key := range keys {
+		for reason := range dropReasons {
+			fmt.Fprintf(cw, "emit_dropped_entries_total{%s,reason=\"%s\"} %d\n",
+				labels(key), emit.DropReason(reason), all[key].dropped[reason].Load())
+		}
+	}
+
+	fmt.Fprint(cw, "# HELP emit_encode_duration_seconds Time spent encoding and writing a log entry.\n# TYPE emit_encode_duration_seconds histogram\n")
+	var cumulative uint64
+	for i, bound := range encodeBuckets {
+		cumulative += c.buckets[i].Load()
+		fmt.Fprintf(cw, "emit_encode_duration_seconds_bucket{le=\"%s\"} %d\n", strconv.FormatFloat(bound, 'g', -1, 64), cumulative)
+	}
+	cumulative += c.buckets[len(encodeBuckets)].Load()
+	fmt.Fprintf(cw, "emit_encode_duration_seconds_bucket{le=\"+Inf\"} %d\n", cumulative)
+	fmt.Fprintf(cw, "emit_encode_duration_seconds_sum %s\n", strconv.FormatFloat(float64(c.sumNanos.Load())/1e9, 'g', -1, 64))
+	fmt.Fprintf(cw, "emit_encode_duration_seconds_count %d\n", c.count.Load())
+
+	if cw.err == nil {
+		cw.err = cw.w.Flush()
+	}
+	return cw.n, cw.err
+}
+
+// labels formats the level and component labels of a series
+func labels(key seriesKey) string {
+	return `level="` + key.level.String() + `",component="` + escapeLabel(key.component) + `"`
+}
+
+// escapeLabel escapes a label value for the text format
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+// countingWriter counts bytes and keeps the first error
+type countingWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	if cw.err != nil {
+		return 0, cw.err
+	}
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	cw.err = err
+	return n, err
+}
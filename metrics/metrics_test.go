@@ -0,0 +1,80 @@
+package metrics
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cloudresty/emit"
+)
+
+// failingWriter fails every write
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+// TestCollector tests the counters reported by the default logger
+func TestCollector(t *testing.T) {
+	collector := New()
+	emit.SetMetrics(collector)
+	defer emit.SetMetrics(nil)
+
+	var buf strings.Builder
+	emit.SetOutput(&buf)
+	emit.SetComponent("api")
+	emit.SetLevel("info")
+	emit.SetFormat("json")
+	defer emit.SetComponent("")
+
+	emit.Info.Msg("started")
+	emit.Info.StructuredFields("login", emit.ZString("password", "hunter2"), emit.ZInt("attempt", 1))
+	emit.Warn.KeyValue("slow request", "token", "abc", "duration_ms", 1200)
+
+	emit.AddHook(emit.HookFunc(func(entry *emit.Entry) bool {
+		return entry.Message() != "vetoed"
+	}))
+	emit.Info.Msg("vetoed")
+	emit.ClearHooks()
+
+	emit.SetOutput(failingWriter{})
+	emit.Error.Msg("lost")
+
+	recorder := httptest.NewRecorder()
+	collector.Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	body := recorder.Body.String()
+
+	if ct := recorder.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Unexpected content type %q", ct)
+	}
+
+	for _, want := range []string{
+		`emit_entries_total{level="info",component="api"} 2`,
+		`emit_entries_total{level="warn",component="api"} 1`,
+		`emit_write_errors_total{level="error",component="api"} 1`,
+		`emit_masked_fields_total{level="info",component="api"} 1`,
+		`emit_masked_fields_total{level="warn",component="api"} 1`,
+		`emit_dropped_entries_total{level="info",component="api",reason="hook"} 1`,
+		`emit_encode_duration_seconds_count 3`,
+		`emit_encode_duration_seconds_bucket{le="+Inf"} 3`,
+		"# TYPE emit_encode_duration_seconds histogram",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Missing %q in:\n%s", want, body)
+		}
+	}
+
+	written := strings.Count(buf.String(), "\n")
+	if !strings.Contains(body, `emit_bytes_written_total{level="info",component="api"}`) || written != 3 {
+		t.Errorf("Expected 3 written entries and a bytes counter, got %d:\n%s", written, body)
+	}
+}
+
+// TestEscapeLabel tests label value escaping
+func TestEscapeLabel(t *testing.T) {
+	if got := escapeLabel("a\"b\\c\nd"); got != `a\"b\\c\nd` {
+		t.Errorf("Unexpected escaped label %q", got)
+	}
+}
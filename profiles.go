@@ -0,0 +1,296 @@
+package emit
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// ECS version written in the ecs.version field
+const ecsVersion = "8.11.0"
+
+var (
+	// ecsLayout writes Elastic Common Schema entries
+	ecsLayout = &entryLayout{enc: jsonEncoder{}, shape: shapeECS}
+
+	// otelLayout writes entries in the OpenTelemetry logs data model
+	otelLayout = &entryLayout{enc: jsonEncoder{}, shape: shapeOTel}
+)
+
+// parseProfile returns the layout for a profile name (nil for emit's own schema)
+func parseProfile(profile string) *entryLayout {
+	switch strings.ToLower(profile) {
+	case "ecs", "elastic":
+		return ecsLayout
+	case "otel", "opentelemetry":
+		return otelLayout
+	case "gcp", "google", "stackdriver":
+		return newGCPLayout(GCPConfig{})
+	case "cloudwatch", "aws":
+		return newCloudWatchLayout(CloudWatchConfig{})
+	default:
+		return nil
+	}
+}
+
+// SetProfile selects the JSON schema of the default logger's entries:
+// "ecs" (Elastic Common Schema), "otel" (OpenTelemetry logs data model),
+// "gcp" (Google Cloud Logging), "cloudwatch" (AWS CloudWatch Logs) or
+// "emit" (the default emit keys)
+func SetProfile(profile string) {
+	if defaultLogger != nil {
+		defaultLogger.profile = parseProfile(profile)
+	}
+}
+
+// logEntryKeys are emit's JSON keys, taken from the LogEntry json tags
+var logEntryKeys = keysFromLogEntry()
+
+// logEntryLayout writes LogEntry through an encoder, for timestamps the
+// LogEntry string field cannot hold
+var logEntryLayout = &entryLayout{enc: jsonEncoder{}, keys: logEntryKeys, shape: shapeLogEntry}
+
+// keysFromLogEntry reads the built-in keys from the LogEntry json tags
+func keysFromLogEntry() entryKeys {
+	entryType := reflect.TypeOf(LogEntry{})
+	key := func(name string) string {
+		field, _ := entryType.FieldByName(name)
+		tag, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		return tag
+	}
+
+	return entryKeys{
+		time:      key("Timestamp"),
+		level:     key("Level"),
+		message:   key("Message"),
+		component: key("Component"),
+		version:   key("Version"),
+		caller:    "caller",
+		file:      key("File"),
+		line:      key("Line"),
+		function:  key("Function"),
+		fields:    key("Fields"),
+	}
+}
+
+// KeyMap renames the keys of emit's JSON schema. It is keyed by the LogEntry
+// JSON names (timestamp, level, message, component, version, file, line,
+// function and fields); keys not listed keep their name.
+type KeyMap map[string]string
+
+// apply returns keys with the renames applied
+func (m KeyMap) apply(keys entryKeys) entryKeys {
+	targets := map[string]*string{
+		logEntryKeys.time:      &keys.time,
+		logEntryKeys.level:     &keys.level,
+		logEntryKeys.message:   &keys.message,
+		logEntryKeys.component: &keys.component,
+		logEntryKeys.version:   &keys.version,
+		logEntryKeys.file:      &keys.file,
+		logEntryKeys.line:      &keys.line,
+		logEntryKeys.function:  &keys.function,
+		logEntryKeys.fields:    &keys.fields,
+	}
+	for from, to := range m {
+		if target, ok := targets[from]; ok && to != "" {
+			*target = to
+		}
+	}
+	return keys
+}
+
+// SetKeyMap renames the JSON keys of the default logger's entries,
+// replacing any profile
+func SetKeyMap(keys KeyMap) {
+	if defaultLogger != nil {
+		defaultLogger.profile = &entryLayout{enc: jsonEncoder{}, keys: keys.apply(logEntryKeys), shape: shapeLogEntry}
+	}
+}
+
+// appendLogEntry appends an entry shaped like LogEntry with its keys
+// renamed. KeyMap layouts keep logJSON's shape (map fields nested,
+// structured fields at the top level); EncoderConfig layouts place both
+// the same way.
+func (k *entryLayout) appendLogEntry(l *Logger, buf []byte, level LogLevel, message string, fields map[string]any, zfields []ZField) ([]byte, int) {
+	enc := k.enc.(objectEncoder)
+	buf = enc.begin(buf)
+	buf = enc.appendEntryTime(buf, k.keys.time, l.times(), l.now())
+	buf = k.appendLevel(enc, buf, level)
+	buf = enc.appendString(buf, k.keys.message, message)
+
+	if l.component != "" {
+		buf = enc.appendString(buf, k.keys.component, l.component)
+	}
+	if l.version != "" {
+		buf = enc.appendString(buf, k.keys.version, l.version)
+	}
+	if l.showCaller {
+		if frame, ok := l.caller(); ok {
+			if k.keys.file != "" {
+				buf = enc.appendString(buf, k.keys.file, l.callerFile(frame.File))
+				buf = enc.appendInt(buf, k.keys.line, int64(frame.Line))
+			} else {
+				buf = enc.appendString(buf, k.keys.caller, l.callerLine(frame))
+			}
+			if k.keys.function != "" {
+				buf = enc.appendString(buf, k.keys.function, frame.Function)
+			}
+		}
+	}
+
+	masked := 0
+	switch {
+	case k.fields == fieldsFlat || k.fields == fieldsByAPI && zfields != nil:
+		buf, masked = l.appendUserFields(enc, buf, fields, zfields, k.filter)
+	case len(fields) > 0 || len(zfields) > 0:
+		buf = enc.openObject(buf, k.keys.fields)
+		buf, masked = l.appendUserFields(enc, buf, fields, zfields, nil)
+		buf = enc.closeObject(buf)
+	}
+
+	return enc.end(l.appendStack(enc, l.appendEnrichment(enc, buf), level)), masked
+}
+
+// errorKeys are the field names treated as the entry's error by profiles
+// with a dedicated error schema
+var errorKeys = [...]string{"error", "err"}
+
+// errorField returns the key, message and type of the entry's error field
+func errorField(fields map[string]any, zfields []ZField) (key, message, errType string, ok bool) {
+	for _, key := range errorKeys {
+		if zfields != nil {
+			for _, field := range zfields {
+				if f, isString := field.(StringZField); isString && f.Key == key {
+					return key, f.Value, "", true
+				}
+			}
+			continue
+		}
+
+		switch v := fields[key].(type) {
+		case error:
+			return key, v.Error(), fmt.Sprintf("%T", v), true
+		case string:
+			return key, v, "", true
+		}
+	}
+	return "", "", "", false
+}
+
+// appendECS appends an Elastic Common Schema entry: @timestamp, log.level,
+// message and ecs.version, then service, log.origin and error objects, then
+// the fields at the top level
+func (k *entryLayout) appendECS(l *Logger, buf []byte, level LogLevel, message string, fields map[string]any, zfields []ZField) ([]byte, int) {
+	enc := k.enc.(objectEncoder)
+	buf = enc.begin(buf)
+	buf = enc.appendEntryTime(buf, "@timestamp", l.times(), l.now())
+	buf = enc.appendString(buf, "log.level", level.StringFast())
+	buf = enc.appendString(buf, "message", message)
+	buf = enc.appendString(buf, "ecs.version", ecsVersion)
+
+	if l.component != "" || l.version != "" {
+		buf = enc.openObject(buf, "service")
+		if l.component != "" {
+			buf = enc.appendString(buf, "name", l.component)
+		}
+		if l.version != "" {
+			buf = enc.appendString(buf, "version", l.version)
+		}
+		buf = enc.closeObject(buf)
+	}
+
+	if l.showCaller {
+		if frame, ok := l.caller(); ok {
+			buf = enc.openObject(buf, "log")
+			buf = enc.openObject(buf, "origin")
+			buf = enc.openObject(buf, "file")
+			buf = enc.appendString(buf, "name", filepath.Base(frame.File))
+			buf = enc.appendInt(buf, "line", int64(frame.Line))
+			buf = enc.closeObject(buf)
+			buf = enc.appendString(buf, "function", frame.Function)
+			buf = enc.closeObject(buf)
+			buf = enc.closeObject(buf)
+		}
+	}
+
+	var filter *fieldFilter
+	if errKey, errMessage, errType, ok := errorField(fields, zfields); ok {
+		buf = enc.openObject(buf, "error")
+		buf = enc.appendString(buf, "message", errMessage)
+		if errType != "" {
+			buf = enc.appendString(buf, "type", errType)
+		}
+		buf = enc.closeObject(buf)
+		filter = &fieldFilter{skip: []string{errKey}}
+	}
+
+	buf, masked := l.appendUserFields(enc, buf, fields, zfields, filter)
+	return enc.end(l.appendStack(enc, l.appendEnrichment(enc, buf), level)), masked
+}
+
+// otelSeverity returns the OpenTelemetry severity text and number of a level
+func otelSeverity(level LogLevel) (string, int64) {
+	switch level {
+	case DEBUG:
+		return "DEBUG", 5
+	case WARN:
+		return "WARN", 13
+	case ERROR:
+		return "ERROR", 17
+	default:
+		return "INFO", 9
+	}
+}
+
+// appendOTel appends an entry in the OpenTelemetry logs data model: the
+// component and version become Resource attributes, and the fields, caller
+// and error become Attributes using the semantic convention names
+func (k *entryLayout) appendOTel(l *Logger, buf []byte, level LogLevel, message string, fields map[string]any, zfields []ZField) ([]byte, int) {
+	enc := k.enc.(objectEncoder)
+	severityText, severityNumber := otelSeverity(level)
+
+	buf = enc.begin(buf)
+	buf = enc.appendEntryTime(buf, "Timestamp", l.times(), l.now())
+	buf = enc.appendString(buf, "SeverityText", severityText)
+	buf = enc.appendInt(buf, "SeverityNumber", severityNumber)
+	buf = enc.appendString(buf, "Body", message)
+
+	if l.component != "" || l.version != "" {
+		buf = enc.openObject(buf, "Resource")
+		if l.component != "" {
+			buf = enc.appendString(buf, "service.name", l.component)
+		}
+		if l.version != "" {
+			buf = enc.appendString(buf, "service.version", l.version)
+		}
+		buf = enc.closeObject(buf)
+	}
+
+	masked := 0
+	if len(fields) > 0 || len(zfields) > 0 || l.showCaller {
+		buf = enc.openObject(buf, "Attributes")
+
+		if l.showCaller {
+			if frame, ok := l.caller(); ok {
+				buf = enc.appendString(buf, "code.filepath", l.callerFile(frame.File))
+				buf = enc.appendInt(buf, "code.lineno", int64(frame.Line))
+				buf = enc.appendString(buf, "code.function", frame.Function)
+			}
+		}
+
+		var filter *fieldFilter
+		if errKey, errMessage, errType, ok := errorField(fields, zfields); ok {
+			buf = enc.appendString(buf, "exception.message", errMessage)
+			if errType != "" {
+				buf = enc.appendString(buf, "exception.type", errType)
+			}
+			filter = &fieldFilter{skip: []string{errKey}}
+		}
+
+		buf, masked = l.appendUserFields(enc, buf, fields, zfields, filter)
+		buf = enc.closeObject(buf)
+	}
+
+	return enc.end(l.appendStack(enc, l.appendEnrichment(enc, buf), level)), masked
+}
@@ -0,0 +1,317 @@
+package emit
+
+import (
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Fields given special meaning by the GCP profile
+const (
+	gcpTraceField       = "trace_id"      // Becomes logging.googleapis.com/trace
+	gcpSpanField        = "span_id"       // Becomes logging.googleapis.com/spanId
+	gcpTraceSampleField = "trace_sampled" // Becomes logging.googleapis.com/trace_sampled
+	gcpHTTPRequestField = "httpRequest"   // Map written as the LogEntry httpRequest
+)
+
+// gcpFilter leaves out the trace fields written as correlation keys
+var gcpFilter = &fieldFilter{skip: []string{gcpTraceField, gcpSpanField, gcpTraceSampleField}}
+
+// GCPConfig configures the Google Cloud Logging profile.
+//
+// Entries use severity, time and message, the component and version become
+// serviceContext (for Error Reporting), the caller becomes
+// logging.googleapis.com/sourceLocation, and trace_id, span_id and
+// trace_sampled fields become the trace correlation keys. A map field named
+// httpRequest is written as the HttpRequest object (a time.Duration latency
+// is converted to the "1.5s" form); masking applies to it like any field.
+type GCPConfig struct {
+	// ProjectID turns plain trace IDs into projects/<id>/traces/<trace>
+	// (default: $GOOGLE_CLOUD_PROJECT)
+	ProjectID string
+}
+
+// newGCPLayout builds a GCP layout, defaulting the project from the environment
+func newGCPLayout(config GCPConfig) *entryLayout {
+	if config.ProjectID == "" {
+		config.ProjectID = os.Getenv("GOOGLE_CLOUD_PROJECT")
+	}
+	return &entryLayout{enc: jsonEncoder{}, shape: shapeGCP, gcpProject: config.ProjectID}
+}
+
+// SetGCPProfile selects the Google Cloud Logging profile on the default logger
+func SetGCPProfile(config GCPConfig) {
+	if defaultLogger != nil {
+		defaultLogger.profile = newGCPLayout(config)
+	}
+}
+
+// gcpSeverity returns the Cloud Logging severity of a level
+func gcpSeverity(level LogLevel) string {
+	switch level {
+	case DEBUG:
+		return "DEBUG"
+	case WARN:
+		return "WARNING"
+	case ERROR:
+		return "ERROR"
+	default:
+		return "INFO"
+	}
+}
+
+// appendGCP appends a Google Cloud Logging structured entry
+func (k *entryLayout) appendGCP(l *Logger, buf []byte, level LogLevel, message string, fields map[string]any, zfields []ZField) ([]byte, int) {
+	enc := k.enc.(objectEncoder)
+	buf = enc.begin(buf)
+	buf = enc.appendEntryTime(buf, "time", l.times(), l.now())
+	buf = enc.appendString(buf, "severity", gcpSeverity(level))
+	buf = enc.appendString(buf, "message", message)
+
+	if l.component != "" || l.version != "" {
+		buf = enc.openObject(buf, "serviceContext")
+		if l.component != "" {
+			buf = enc.appendString(buf, "service", l.component)
+		}
+		if l.version != "" {
+			buf = enc.appendString(buf, "version", l.version)
+		}
+		buf = enc.closeObject(buf)
+	}
+
+	if l.showCaller {
+		if frame, ok := l.caller(); ok {
+			buf = enc.openObject(buf, "logging.googleapis.com/sourceLocation")
+			buf = enc.appendString(buf, "file", l.callerFile(frame.File))
+			buf = enc.appendString(buf, "line", strconv.Itoa(frame.Line))
+			buf = enc.appendString(buf, "function", frame.Function)
+			buf = enc.closeObject(buf)
+		}
+	}
+
+	if trace, ok := stringField(fields, zfields, gcpTraceField); ok {
+		if k.gcpProject != "" && !strings.HasPrefix(trace, "projects/") {
+			trace = "projects/" + k.gcpProject + "/traces/" + trace
+		}
+		buf = enc.appendString(buf, "logging.googleapis.com/trace", trace)
+	}
+	if span, ok := stringField(fields, zfields, gcpSpanField); ok {
+		buf = enc.appendString(buf, "logging.googleapis.com/spanId", span)
+	}
+	if sampled, ok := boolField(fields, zfields, gcpTraceSampleField); ok {
+		buf = enc.appendBool(buf, "logging.googleapis.com/trace_sampled", sampled)
+	}
+
+	buf, masked := l.appendUserFields(enc, buf, gcpHTTPRequestLatency(fields), zfields, gcpFilter)
+	return enc.end(l.appendStack(enc, l.appendEnrichment(enc, buf), level)), masked
+}
+
+// gcpHTTPRequestLatency converts a time.Duration httpRequest latency to the
+// protobuf Duration string Cloud Logging expects
+func gcpHTTPRequestLatency(fields map[string]any) map[string]any {
+	request, ok := fields[gcpHTTPRequestField].(map[string]any)
+	if !ok {
+		return fields
+	}
+	latency, ok := request["latency"].(time.Duration)
+	if !ok {
+		return fields
+	}
+
+	converted := make(map[string]any, len(request))
+	for key, value := range request {
+		converted[key] = value
+	}
+	converted["latency"] = strconv.FormatFloat(latency.Seconds(), 'f', -1, 64) + "s"
+
+	copied := make(map[string]any, len(fields))
+	for key, value := range fields {
+		copied[key] = value
+	}
+	copied[gcpHTTPRequestField] = converted
+	return copied
+}
+
+// stringField returns a string field of the entry
+func stringField(fields map[string]any, zfields []ZField, key string) (string, bool) {
+	if zfields != nil {
+		for _, field := range zfields {
+			if f, ok := field.(StringZField); ok && f.Key == key {
+				return f.Value, true
+			}
+		}
+		return "", false
+	}
+	value, ok := fields[key].(string)
+	return value, ok
+}
+
+// boolField returns a bool field of the entry
+func boolField(fields map[string]any, zfields []ZField, key string) (bool, bool) {
+	if zfields != nil {
+		for _, field := range zfields {
+			if f, ok := field.(BoolZField); ok && f.Key == key {
+				return f.Value, true
+			}
+		}
+		return false, false
+	}
+	value, ok := fields[key].(bool)
+	return value, ok
+}
+
+// numericField reports whether the entry has a numeric field
+func numericField(fields map[string]any, zfields []ZField, key string) bool {
+	if zfields != nil {
+		for _, field := range zfields {
+			switch f := field.(type) {
+			case IntZField:
+				if f.Key == key {
+					return true
+				}
+			case Int64ZField:
+				if f.Key == key {
+					return true
+				}
+			case Float64ZField:
+				if f.Key == key {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	switch fields[key].(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return true
+	default:
+		return false
+	}
+}
+
+// CloudWatchConfig configures the CloudWatch profile's Embedded Metric
+// Format (EMF). Entries are emit JSON with fields at the top level; when an
+// entry carries one of Metrics as a numeric field, an _aws directive is
+// added so CloudWatch extracts it as a metric.
+type CloudWatchConfig struct {
+	Namespace string
+
+	// Dimensions are sets of top-level keys to aggregate metrics by,
+	// e.g. {{"component"}} (default: no dimensions)
+	Dimensions [][]string
+
+	// Metrics maps field names to CloudWatch units such as "Milliseconds",
+	// "Bytes" or "Count" (empty means "None")
+	Metrics map[string]string
+}
+
+// emfDirective holds the metric declarations of a CloudWatch layout
+type emfDirective struct {
+	namespace  string
+	dimensions [][]string
+	names      []string
+	units      []string
+}
+
+// newCloudWatchLayout builds a CloudWatch layout (without EMF if no metrics are configured)
+func newCloudWatchLayout(config CloudWatchConfig) *entryLayout {
+	layout := &entryLayout{enc: jsonEncoder{}, keys: logEntryKeys, shape: shapeCloudWatch}
+	if len(config.Metrics) == 0 {
+		return layout
+	}
+
+	directive := &emfDirective{namespace: config.Namespace, dimensions: config.Dimensions}
+	for name := range config.Metrics {
+		directive.names = append(directive.names, name)
+	}
+	slices.Sort(directive.names)
+	for _, name := range directive.names {
+		unit := config.Metrics[name]
+		if unit == "" {
+			unit = "None"
+		}
+		directive.units = append(directive.units, unit)
+	}
+
+	layout.emf = directive
+	return layout
+}
+
+// SetCloudWatchProfile selects the CloudWatch profile with Embedded Metric Format on the default logger
+func SetCloudWatchProfile(config CloudWatchConfig) {
+	if defaultLogger != nil {
+		defaultLogger.profile = newCloudWatchLayout(config)
+	}
+}
+
+// appendCloudWatch appends a keyed entry, preceded by an EMF directive
+// declaring the configured metrics present in it
+func (k *entryLayout) appendCloudWatch(l *Logger, buf []byte, level LogLevel, message string, fields map[string]any, zfields []ZField) ([]byte, int) {
+	buf = k.enc.begin(buf)
+	if k.emf != nil {
+		buf = k.emf.append(buf, l.now(), fields, zfields)
+	}
+
+	buf, masked := k.appendKeyedBody(l, buf, level, message, fields, zfields)
+	return k.enc.end(buf), masked
+}
+
+// append writes the _aws directive if the entry has any declared metric
+func (d *emfDirective) append(buf []byte, now time.Time, fields map[string]any, zfields []ZField) []byte {
+	present := 0
+	for _, name := range d.names {
+		if numericField(fields, zfields, name) {
+			present++
+		}
+	}
+	if present == 0 {
+		return buf
+	}
+
+	enc := jsonEncoder{}
+	buf = enc.openObject(buf, "_aws")
+	buf = enc.appendInt(buf, "Timestamp", now.UnixMilli())
+	buf = append(buf, `,"CloudWatchMetrics":[{"Namespace":`...)
+	buf = appendJSONString(buf, d.namespace)
+
+	buf = append(buf, `,"Dimensions":[`...)
+	if len(d.dimensions) == 0 {
+		buf = append(buf, "[]"...)
+	}
+	for i, set := range d.dimensions {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		buf = append(buf, '[')
+		for j, key := range set {
+			if j > 0 {
+				buf = append(buf, ',')
+			}
+			buf = appendJSONString(buf, key)
+		}
+		buf = append(buf, ']')
+	}
+
+	buf = append(buf, `],"Metrics":[`...)
+	written := 0
+	for i, name := range d.names {
+		if !numericField(fields, zfields, name) {
+			continue
+		}
+		if written > 0 {
+			buf = append(buf, ',')
+		}
+		buf = append(buf, `{"Name":`...)
+		buf = appendJSONString(buf, name)
+		buf = append(buf, `,"Unit":`...)
+		buf = appendJSONString(buf, d.units[i])
+		buf = append(buf, '}')
+		written++
+	}
+	buf = append(buf, "]}]"...)
+
+	return enc.closeObject(buf)
+}
@@ -0,0 +1,230 @@
+package emit
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// decodeLines decodes one JSON object per line
+func decodeLines(t *testing.T, output string) []map[string]any {
+	t.Helper()
+
+	var entries []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("Invalid JSON %q: %v", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// TestECSProfile tests the ECS layout across the JSON paths
+func TestECSProfile(t *testing.T) {
+	var buf bytes.Buffer
+	testLogger := newTestLogger(&buf, withComponent("checkout", "2.1.0"), withProfile("ecs"))
+
+	testLogger.log(INFO, "started", nil)
+	testLogger.log(ERROR, "payment failed", map[string]any{"error": errors.New("card declined"), "password": "x", "order_id": 42})
+	testLogger.logStructuredFields(WARN, "slow", ZString("err", "timeout"), ZInt("attempt", 2))
+	testLogger.showCaller = true
+	testLogger.logJSON(DEBUG, "with caller", nil)
+
+	entries := decodeLines(t, buf.String())
+	if len(entries) != 4 {
+		t.Fatalf("Expected 4 entries, got %d", len(entries))
+	}
+
+	for _, entry := range entries {
+		if entry["@timestamp"] == nil || entry["ecs.version"] != ecsVersion {
+			t.Errorf("Missing ECS base fields: %v", entry)
+		}
+		service, _ := entry["service"].(map[string]any)
+		if service["name"] != "checkout" || service["version"] != "2.1.0" {
+			t.Errorf("Unexpected service object: %v", entry["service"])
+		}
+	}
+
+	if entries[0]["log.level"] != "info" || entries[0]["message"] != "started" {
+		t.Errorf("Unexpected simple entry: %v", entries[0])
+	}
+
+	failed := entries[1]
+	errObject, _ := failed["error"].(map[string]any)
+	if errObject["message"] != "card declined" || errObject["type"] != "*errors.errorString" {
+		t.Errorf("Unexpected error object: %v", failed["error"])
+	}
+	if failed["password"] != "***MASKED***" || failed["order_id"] != float64(42) {
+		t.Errorf("Expected masked top-level fields: %v", failed)
+	}
+
+	slow := entries[2]
+	if errObject, _ := slow["error"].(map[string]any); errObject["message"] != "timeout" || slow["err"] != nil {
+		t.Errorf("Expected the err field moved to error.message: %v", slow)
+	}
+	if slow["attempt"] != float64(2) {
+		t.Errorf("Missing structured field: %v", slow)
+	}
+
+	origin, _ := entries[3]["log"].(map[string]any)["origin"].(map[string]any)
+	file, _ := origin["file"].(map[string]any)
+	if file["name"] != "profiles_test.go" || file["line"] == nil {
+		t.Errorf("Unexpected log.origin: %v", entries[3]["log"])
+	}
+}
+
+// TestOTelProfile tests the OpenTelemetry layout across the JSON paths
+func TestOTelProfile(t *testing.T) {
+	var buf bytes.Buffer
+	testLogger := newTestLogger(&buf, withComponent("checkout", "2.1.0"), withProfile("otel"))
+
+	testLogger.log(WARN, "disk almost full", nil)
+	testLogger.logStructuredFields(ERROR, "write failed", ZString("error", "EIO"), ZString("token", "abc"), ZInt("fd", 3))
+	testLogger.log(INFO, "request", map[string]any{"http": map[string]any{"status": 200}})
+
+	entries := decodeLines(t, buf.String())
+
+	simple := entries[0]
+	if simple["SeverityText"] != "WARN" || simple["SeverityNumber"] != float64(13) || simple["Body"] != "disk almost full" {
+		t.Errorf("Unexpected simple entry: %v", simple)
+	}
+	if _, ok := simple["Attributes"]; ok {
+		t.Errorf("Expected no Attributes without fields: %v", simple)
+	}
+	resource, _ := simple["Resource"].(map[string]any)
+	if resource["service.name"] != "checkout" || resource["service.version"] != "2.1.0" {
+		t.Errorf("Unexpected Resource: %v", simple["Resource"])
+	}
+
+	attributes, _ := entries[1]["Attributes"].(map[string]any)
+	if attributes["exception.message"] != "EIO" || attributes["error"] != nil {
+		t.Errorf("Expected the error as exception.message: %v", attributes)
+	}
+	if attributes["token"] != "***MASKED***" || attributes["fd"] != float64(3) {
+		t.Errorf("Unexpected structured attributes: %v", attributes)
+	}
+
+	attributes, _ = entries[2]["Attributes"].(map[string]any)
+	if http, _ := attributes["http"].(map[string]any); http["status"] != float64(200) {
+		t.Errorf("Expected nested attributes: %v", attributes)
+	}
+}
+
+// TestProfileStructuredNoAllocation tests that profiles keep the structured path allocation-free
+func TestProfileStructuredNoAllocation(t *testing.T) {
+	testLogger := newTestLogger(io.Discard, withLevel(INFO), withProfile("otel"))
+
+	fields := []ZField{ZString("k", "v"), ZInt("n", 1)}
+	allocs := testing.AllocsPerRun(100, func() {
+		testLogger.logStructuredFields(INFO, "hot path", fields...)
+	})
+	if allocs != 0 {
+		t.Errorf("Expected 0 allocations, got %v", allocs)
+	}
+}
+
+// TestGCPProfile tests Cloud Logging keys, trace correlation and httpRequest
+func TestGCPProfile(t *testing.T) {
+	var buf bytes.Buffer
+	testLogger := newTestLogger(&buf, withComponent("checkout", "2.1.0"))
+	testLogger.profile = newGCPLayout(GCPConfig{ProjectID: "shop-prod"})
+	testLogger.showCaller = true
+
+	testLogger.log(WARN, "slow request", map[string]any{
+		"trace_id":      "4bf92f3577b34da6a3ce929d0e0e4736",
+		"span_id":       "00f067aa0ba902b7",
+		"trace_sampled": true,
+		"httpRequest":   map[string]any{"requestMethod": "GET", "status": 200, "latency": 1500 * time.Millisecond},
+	})
+	testLogger.logStructuredFields(ERROR, "failed", ZString("trace_id", "projects/other/traces/abc"), ZInt("code", 7))
+
+	entries := decodeLines(t, buf.String())
+	first := entries[0]
+	if first["severity"] != "WARNING" || first["message"] != "slow request" || first["time"] == nil {
+		t.Errorf("Unexpected GCP base fields: %v", first)
+	}
+	if first["logging.googleapis.com/trace"] != "projects/shop-prod/traces/4bf92f3577b34da6a3ce929d0e0e4736" ||
+		first["logging.googleapis.com/spanId"] != "00f067aa0ba902b7" ||
+		first["logging.googleapis.com/trace_sampled"] != true {
+		t.Errorf("Unexpected trace correlation: %v", first)
+	}
+	if first["trace_id"] != nil || first["span_id"] != nil {
+		t.Errorf("Trace fields should not be repeated: %v", first)
+	}
+
+	request, _ := first["httpRequest"].(map[string]any)
+	if request["latency"] != "1.5s" || request["status"] != float64(200) {
+		t.Errorf("Unexpected httpRequest: %v", first["httpRequest"])
+	}
+	location, _ := first["logging.googleapis.com/sourceLocation"].(map[string]any)
+	if !strings.HasSuffix(location["file"].(string), "profiles_test.go") || location["line"] == "" {
+		t.Errorf("Unexpected sourceLocation: %v", location)
+	}
+	if context, _ := first["serviceContext"].(map[string]any); context["service"] != "checkout" {
+		t.Errorf("Unexpected serviceContext: %v", first["serviceContext"])
+	}
+
+	if entries[1]["logging.googleapis.com/trace"] != "projects/other/traces/abc" || entries[1]["code"] != float64(7) {
+		t.Errorf("Unexpected structured GCP entry: %v", entries[1])
+	}
+}
+
+// TestCloudWatchProfile tests the Embedded Metric Format directive
+func TestCloudWatchProfile(t *testing.T) {
+	var buf bytes.Buffer
+	testLogger := newTestLogger(&buf, withComponent("checkout", "2.1.0"))
+	testLogger.profile = newCloudWatchLayout(CloudWatchConfig{
+		Namespace:  "Checkout",
+		Dimensions: [][]string{{"component"}},
+		Metrics:    map[string]string{"latency_ms": "Milliseconds", "items": ""},
+	})
+
+	testLogger.logStructuredFields(INFO, "order placed", ZFloat64("latency_ms", 12.5), ZString("order_id", "o-1"))
+	testLogger.log(INFO, "no metrics", map[string]any{"order_id": "o-2"})
+
+	entries := decodeLines(t, buf.String())
+	aws, _ := entries[0]["_aws"].(map[string]any)
+	if aws == nil || aws["Timestamp"] == nil {
+		t.Fatalf("Expected an _aws directive: %v", entries[0])
+	}
+	directive := aws["CloudWatchMetrics"].([]any)[0].(map[string]any)
+	metrics := directive["Metrics"].([]any)
+	if directive["Namespace"] != "Checkout" || len(metrics) != 1 || metrics[0].(map[string]any)["Unit"] != "Milliseconds" {
+		t.Errorf("Unexpected directive: %v", directive)
+	}
+	if entries[0]["latency_ms"] != 12.5 || entries[0]["component"] != "checkout" || entries[0]["message"] != "order placed" {
+		t.Errorf("Expected top-level metric and dimension values: %v", entries[0])
+	}
+
+	if _, ok := entries[1]["_aws"]; ok || entries[1]["order_id"] != "o-2" {
+		t.Errorf("Expected no directive without metrics: %v", entries[1])
+	}
+}
+
+// TestKeyMap tests renaming emit's JSON keys
+func TestKeyMap(t *testing.T) {
+	var buf bytes.Buffer
+	testLogger := newTestLogger(&buf, withComponent("checkout", "2.1.0"))
+	testLogger.profile = &entryLayout{
+		enc:   jsonEncoder{},
+		keys:  KeyMap{"timestamp": "ts", "message": "msg", "fields": "data"}.apply(logEntryKeys),
+		shape: shapeLogEntry,
+	}
+
+	testLogger.log(INFO, "renamed", map[string]any{"user_id": 1})
+	entries := decodeLines(t, buf.String())
+
+	data, _ := entries[0]["data"].(map[string]any)
+	if entries[0]["ts"] == nil || entries[0]["msg"] != "renamed" || entries[0]["level"] != "info" || data["user_id"] != float64(1) {
+		t.Errorf("Unexpected renamed entry: %v", entries[0])
+	}
+	if logEntryKeys.time != "timestamp" || logEntryKeys.fields != "fields" {
+		t.Errorf("Unexpected LogEntry keys: %+v", logEntryKeys)
+	}
+}
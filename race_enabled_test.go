@@ -0,0 +1,7 @@
+//go:build race
+
+package emit
+
+// raceEnabled reports whether tests run with the race detector, under which
+// sync.Pool drops items and allocation counts are not meaningful
+const raceEnabled = true
@@ -0,0 +1,367 @@
+package emit
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DedupMode selects how repeated messages are collapsed
+type DedupMode int
+
+const (
+	DEDUP_OFF         DedupMode = iota // Log every entry
+	DEDUP_CONSECUTIVE                  // Collapse identical consecutive entries
+	DEDUP_WINDOW                       // Collapse identical entries within DedupWindow
+)
+
+// RateLimit is a token bucket: Rate entries per second with bursts up to Burst
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// RateLimitConfig configures rate limiting and duplicate suppression.
+//
+// Suppressed duplicates are reported by a single entry carrying the original
+// level and message plus a "repeated" field with the number of entries
+// collapsed, emitted when the burst ends (a different message arrives in
+// consecutive mode) or when DedupWindow elapses.
+type RateLimitConfig struct {
+	// PerLevel limits entries of each level (levels not listed are unlimited)
+	PerLevel map[LogLevel]RateLimit
+
+	// Logger limits all entries written by the logger
+	Logger RateLimit
+
+	// Dedup selects duplicate suppression; entries are identical when they
+	// share level and message
+	Dedup DedupMode
+
+	// DedupWindow bounds how long duplicates are collapsed before the
+	// "repeated" entry is written (default: 1s)
+	DedupWindow time.Duration
+}
+
+// RateLimitStats reports entries suppressed by the rate limiter
+type RateLimitStats struct {
+	Limited      uint64 // Dropped by a token bucket
+	Deduplicated uint64 // Collapsed into a "repeated" entry
+}
+
+// tokenBucket is a mutex-guarded token bucket
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   int64
+}
+
+// newTokenBucket returns nil for an unlimited configuration
+func newTokenBucket(limit RateLimit) *tokenBucket {
+	if limit.Rate <= 0 {
+		return nil
+	}
+	burst := float64(limit.Burst)
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{rate: limit.Rate, burst: burst, tokens: burst}
+}
+
+// allow takes a token if one is available
+func (b *tokenBucket) allow(now int64) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.last != 0 {
+		b.tokens += float64(now-b.last) / float64(time.Second) * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// dedupKey identifies identical entries
+type dedupKey struct {
+	level   LogLevel
+	message string
+}
+
+// dedupBurst counts duplicates of one entry within a window (window mode)
+type dedupBurst struct {
+	start int64
+	count int
+}
+
+// rateLimiter wraps the logger core with token buckets and deduplication
+type rateLimiter struct {
+	levels [ERROR + 1]*tokenBucket
+	logger *tokenBucket
+
+	dedup  DedupMode
+	window time.Duration
+	clock  Clock
+	emit   func(level LogLevel, message string, repeated int)
+
+	mu sync.Mutex
+
+	// Consecutive mode: the last entry and its repeats since runStart
+	last     dedupKey
+	hasLast  bool
+	count    int
+	runStart int64
+
+	// Window mode: open bursts
+	bursts map[dedupKey]*dedupBurst
+
+	// Both modes: runs and bursts are expired by a sweeper goroutine
+	stop chan struct{}
+
+	limited      atomic.Uint64
+	deduplicated atomic.Uint64
+}
+
+// newRateLimiter builds a limiter that writes "repeated" entries through emit
+func newRateLimiter(config RateLimitConfig, clock Clock, emit func(level LogLevel, message string, repeated int)) *rateLimiter {
+	if config.DedupWindow <= 0 {
+		config.DedupWindow = time.Second
+	}
+
+	r := &rateLimiter{
+		logger: newTokenBucket(config.Logger),
+		dedup:  config.Dedup,
+		window: config.DedupWindow,
+		clock:  clock,
+		emit:   emit,
+	}
+	for level, limit := range config.PerLevel {
+		if level >= DEBUG && level <= ERROR {
+			r.levels[level] = newTokenBucket(limit)
+		}
+	}
+
+	if r.dedup == DEDUP_WINDOW {
+		r.bursts = make(map[dedupKey]*dedupBurst)
+	}
+	if r.dedup == DEDUP_CONSECUTIVE || r.dedup == DEDUP_WINDOW {
+		r.stop = make(chan struct{})
+		// Sweep twice per window, with a floor so tiny windows still tick
+		go r.sweep(r.clock.NewTicker(max(r.window/2, time.Millisecond)))
+	}
+
+	return r
+}
+
+// duplicate reports whether an entry is a duplicate to be collapsed
+func (r *rateLimiter) duplicate(level LogLevel, message string) bool {
+	switch r.dedup {
+	case DEDUP_CONSECUTIVE:
+		return r.duplicateConsecutive(dedupKey{level: level, message: message})
+	case DEDUP_WINDOW:
+		return r.duplicateInWindow(dedupKey{level: level, message: message})
+	default:
+		return false
+	}
+}
+
+// duplicateConsecutive collapses runs of the same entry. A run ends when a
+// different entry arrives or DedupWindow after its first repeat.
+func (r *rateLimiter) duplicateConsecutive(key dedupKey) bool {
+	r.mu.Lock()
+
+	if r.hasLast && r.last == key {
+		if r.count == 0 {
+			r.runStart = r.clock.Now().UnixNano()
+		}
+		r.count++
+		r.mu.Unlock()
+		r.deduplicated.Add(1)
+		return true
+	}
+
+	previous, repeated := r.last, r.count
+	r.resetConsecutive()
+	r.last, r.hasLast = key, true
+	r.mu.Unlock()
+
+	if repeated > 0 {
+		r.emit(previous.level, previous.message, repeated)
+	}
+	return false
+}
+
+// expireConsecutive ends a run once its window has elapsed
+func (r *rateLimiter) expireConsecutive(now int64) {
+	r.mu.Lock()
+	if r.count == 0 || now-r.runStart < int64(r.window) {
+		r.mu.Unlock()
+		return
+	}
+	key, repeated := r.last, r.count
+	r.resetConsecutive()
+	r.mu.Unlock()
+
+	if repeated > 0 {
+		r.emit(key.level, key.message, repeated)
+	}
+}
+
+// resetConsecutive clears the current run; callers hold r.mu
+func (r *rateLimiter) resetConsecutive() {
+	r.hasLast = false
+	r.count = 0
+}
+
+// duplicateInWindow collapses every repeat of an entry within the window
+func (r *rateLimiter) duplicateInWindow(key dedupKey) bool {
+	now := r.clock.Now().UnixNano()
+
+	r.mu.Lock()
+	burst, ok := r.bursts[key]
+	if ok && now-burst.start < int64(r.window) {
+		burst.count++
+		r.mu.Unlock()
+		r.deduplicated.Add(1)
+		return true
+	}
+
+	// First occurrence, or the previous window expired before the sweeper ran
+	repeated := 0
+	if ok {
+		repeated = burst.count
+		burst.start, burst.count = now, 0
+	} else {
+		r.bursts[key] = &dedupBurst{start: now}
+	}
+	r.mu.Unlock()
+
+	if repeated > 0 {
+		r.emit(key.level, key.message, repeated)
+	}
+	return false
+}
+
+// sweep closes expired runs and windows, writing their "repeated" entries
+func (r *rateLimiter) sweep(ticker Ticker) {
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C():
+			now := r.clock.Now().UnixNano()
+			if r.dedup == DEDUP_CONSECUTIVE {
+				r.expireConsecutive(now)
+			} else {
+				r.expireWindows(now)
+			}
+		case <-r.stop:
+			return
+		}
+	}
+}
+
+// expireWindows removes bursts whose window has elapsed
+func (r *rateLimiter) expireWindows(now int64) {
+	var keys []dedupKey
+	var counts []int
+
+	r.mu.Lock()
+	for key, burst := range r.bursts {
+		if now-burst.start >= int64(r.window) {
+			if burst.count > 0 {
+				keys = append(keys, key)
+				counts = append(counts, burst.count)
+			}
+			delete(r.bursts, key)
+		}
+	}
+	r.mu.Unlock()
+
+	for i, key := range keys {
+		r.emit(key.level, key.message, counts[i])
+	}
+}
+
+// allow applies the per-level and per-logger token buckets
+func (r *rateLimiter) allow(level LogLevel) bool {
+	var bucket *tokenBucket
+	if level >= DEBUG && level <= ERROR {
+		bucket = r.levels[level]
+	}
+	if bucket == nil && r.logger == nil {
+		return true
+	}
+
+	now := r.clock.Now().UnixNano()
+	if bucket != nil && !bucket.allow(now) {
+		r.limited.Add(1)
+		return false
+	}
+	if r.logger != nil && !r.logger.allow(now) {
+		r.limited.Add(1)
+		return false
+	}
+	return true
+}
+
+// close stops the sweeper and writes the "repeated" entries of open bursts
+func (r *rateLimiter) close() {
+	r.mu.Lock()
+	key, repeated := r.last, r.count
+	r.resetConsecutive()
+	r.mu.Unlock()
+
+	if repeated > 0 {
+		r.emit(key.level, key.message, repeated)
+	}
+
+	if r.stop != nil {
+		close(r.stop)
+		r.expireWindows(int64(^uint64(0) >> 1))
+	}
+}
+
+// emitRepeated writes the summary entry for a collapsed burst, bypassing the limiter
+func (l *Logger) emitRepeated(level LogLevel, message string, repeated int) {
+	if level < l.level {
+		return
+	}
+	l.writeStructuredFields(level, message, ZInt("repeated", repeated))
+}
+
+// SetRateLimit enables rate limiting and duplicate suppression on the default logger
+func SetRateLimit(config RateLimitConfig) {
+	if defaultLogger != nil {
+		DisableRateLimit()
+		defaultLogger.limiter = newRateLimiter(config, defaultLogger.clockOrSystem(), defaultLogger.emitRepeated)
+	}
+}
+
+// DisableRateLimit turns rate limiting off, writing any pending "repeated" entries
+func DisableRateLimit() {
+	if defaultLogger != nil && defaultLogger.limiter != nil {
+		limiter := defaultLogger.limiter
+		defaultLogger.limiter = nil
+		limiter.close()
+	}
+}
+
+// GetRateLimitStats returns the default logger's suppression counters
+func GetRateLimitStats() RateLimitStats {
+	if defaultLogger == nil || defaultLogger.limiter == nil {
+		return RateLimitStats{}
+	}
+	return RateLimitStats{
+		Limited:      defaultLogger.limiter.limited.Load(),
+		Deduplicated: defaultLogger.limiter.deduplicated.Load(),
+	}
+}
@@ -0,0 +1,186 @@
+package emit
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cloudresty/emit/emittest"
+)
+
+// syncBuffer is a bytes.Buffer safe for writes from the sweeper goroutine
+type syncBuffer struct {
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	written chan struct{}
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.written != nil {
+		select {
+		case b.written <- struct{}{}:
+		default:
+		}
+	}
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// waitFor waits until the output contains substr, written by another goroutine
+func (b *syncBuffer) waitFor(t *testing.T, substr string) {
+	t.Helper()
+	b.mu.Lock()
+	if b.written == nil {
+		b.written = make(chan struct{}, 1)
+	}
+	b.mu.Unlock()
+
+	timeout := time.After(5 * time.Second)
+	for !strings.Contains(b.String(), substr) {
+		select {
+		case <-b.written:
+		case <-timeout:
+			t.Fatalf("Timed out waiting for %q in:\n%s", substr, b.String())
+		}
+	}
+}
+
+// newRateLimitedTestLogger returns a test logger limited by config on a fake
+// clock, closing the limiter when the test ends
+func newRateLimitedTestLogger(t *testing.T, writer *syncBuffer, config RateLimitConfig) (*Logger, *emittest.Clock) {
+	clock := emittest.NewClock(time.Date(2024, 3, 9, 8, 0, 0, 0, time.UTC))
+	testLogger := newTestLogger(writer, withClock(clock))
+	testLogger.limiter = newRateLimiter(config, clock, testLogger.emitRepeated)
+	t.Cleanup(testLogger.limiter.close)
+	return testLogger, clock
+}
+
+// TestRateLimitPerLevel tests token buckets per level and per logger
+func TestRateLimitPerLevel(t *testing.T) {
+	var buf syncBuffer
+	testLogger, clock := newRateLimitedTestLogger(t, &buf, RateLimitConfig{
+		PerLevel: map[LogLevel]RateLimit{INFO: {Rate: 1, Burst: 2}},
+		Logger:   RateLimit{Rate: 1, Burst: 5},
+	})
+
+	for i := 0; i < 10; i++ {
+		testLogger.log(INFO, "info entry", nil)
+	}
+	for i := 0; i < 10; i++ {
+		testLogger.logStructuredFields(ERROR, "error entry", ZInt("i", i))
+	}
+
+	output := buf.String()
+	if count := strings.Count(output, "info entry"); count != 2 {
+		t.Errorf("Expected 2 info entries within the level burst, got %d", count)
+	}
+	if count := strings.Count(output, "error entry"); count != 3 {
+		t.Errorf("Expected 3 error entries within the logger burst, got %d", count)
+	}
+	if limited := testLogger.limiter.limited.Load(); limited != 15 {
+		t.Errorf("Expected 15 limited entries, got %d", limited)
+	}
+
+	// Buckets refill as the clock advances
+	clock.Add(time.Second)
+	testLogger.log(INFO, "refilled", nil)
+	if !strings.Contains(buf.String(), "refilled") {
+		t.Errorf("Expected an entry after the bucket refilled:\n%s", buf.String())
+	}
+}
+
+// TestDedupConsecutive tests collapsing runs of identical entries
+func TestDedupConsecutive(t *testing.T) {
+	var buf syncBuffer
+	testLogger, _ := newRateLimitedTestLogger(t, &buf, RateLimitConfig{Dedup: DEDUP_CONSECUTIVE, DedupWindow: time.Hour})
+
+	for i := 0; i < 5; i++ {
+		testLogger.log(WARN, "connection reset", nil)
+	}
+	testLogger.logStructuredFields(INFO, "reconnected", ZInt("attempt", 1))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 lines, got %d:\n%s", len(lines), buf.String())
+	}
+	if !strings.Contains(lines[0], "connection reset") || strings.Contains(lines[0], "repeated") {
+		t.Errorf("First occurrence should be logged as-is: %s", lines[0])
+	}
+	if !strings.Contains(lines[1], `"message":"connection reset"`) || !strings.Contains(lines[1], `"repeated":4`) {
+		t.Errorf("Expected a repeated:4 summary, got: %s", lines[1])
+	}
+	if !strings.Contains(lines[2], "reconnected") {
+		t.Errorf("Expected the new entry after the summary, got: %s", lines[2])
+	}
+}
+
+// TestDedupWindow tests collapsing identical entries within a window
+func TestDedupWindow(t *testing.T) {
+	var buf syncBuffer
+	testLogger, clock := newRateLimitedTestLogger(t, &buf, RateLimitConfig{Dedup: DEDUP_WINDOW, DedupWindow: time.Minute})
+
+	testLogger.log(ERROR, "disk slow", nil)
+	testLogger.log(INFO, "tick", nil)
+	testLogger.log(ERROR, "disk slow", nil)
+	testLogger.log(ERROR, "disk slow", nil)
+	testLogger.log(INFO, "tick", nil)
+
+	output := buf.String()
+	if strings.Count(output, "disk slow") != 1 || strings.Count(output, "tick") != 1 {
+		t.Fatalf("Expected one entry per message inside the window:\n%s", output)
+	}
+
+	// The sweeper writes the summaries once the window elapses
+	clock.Add(time.Minute)
+	buf.waitFor(t, `"message":"disk slow","repeated":2`)
+	buf.waitFor(t, `"message":"tick","repeated":1`)
+}
+
+// TestDedupAfterRateLimit tests that entries dropped by the rate limiter
+// neither start nor extend duplicate runs
+func TestDedupAfterRateLimit(t *testing.T) {
+	var buf syncBuffer
+	testLogger, clock := newRateLimitedTestLogger(t, &buf, RateLimitConfig{
+		PerLevel:    map[LogLevel]RateLimit{INFO: {Rate: 1, Burst: 1}},
+		Dedup:       DEDUP_CONSECUTIVE,
+		DedupWindow: time.Hour,
+	})
+
+	testLogger.log(INFO, "first", nil)
+	testLogger.log(INFO, "queue full", nil) // Rate limited
+
+	clock.Add(time.Second)
+	testLogger.log(INFO, "queue full", nil)
+	testLogger.log(WARN, "drained", nil)
+
+	output := buf.String()
+	if strings.Count(output, "queue full") != 1 || strings.Contains(output, "repeated") {
+		t.Errorf("Expected the admitted entry logged once without a summary:\n%s", output)
+	}
+	if deduplicated := testLogger.limiter.deduplicated.Load(); deduplicated != 0 {
+		t.Errorf("Expected no deduplicated entries, got %d", deduplicated)
+	}
+}
+
+// TestDedupTinyWindow tests that a 1ns window does not give the sweeper
+// a zero interval
+func TestDedupTinyWindow(t *testing.T) {
+	var buf syncBuffer
+	testLogger, clock := newRateLimitedTestLogger(t, &buf, RateLimitConfig{Dedup: DEDUP_WINDOW, DedupWindow: time.Nanosecond})
+
+	testLogger.log(WARN, "flapping", nil)
+	clock.Add(time.Millisecond)
+	testLogger.log(WARN, "flapping", nil)
+	if count := strings.Count(buf.String(), "flapping"); count != 2 {
+		t.Errorf("Expected every entry outside the 1ns window, got %d:\n%s", count, buf.String())
+	}
+}
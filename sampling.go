@@ -0,0 +1,159 @@
+package emit
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// SamplingDecision reports what the sampler did with an entry
+type SamplingDecision int
+
+const (
+	LogSampled SamplingDecision = iota // Entry was logged
+	LogDropped                         // Entry was dropped by the sampler
+)
+
+// SamplingConfig configures per-message log sampling.
+//
+// Within each Tick, the first First entries with the same level and message
+// are logged, then every Thereafter-th entry; the rest are dropped.
+type SamplingConfig struct {
+	Tick       time.Duration // Sampling window (default: 1s)
+	First      int           // Entries logged unconditionally per window
+	Thereafter int           // Log every Mth entry after First (0 drops the rest)
+
+	// Hook is called for every sampling decision (optional)
+	Hook func(level LogLevel, message string, decision SamplingDecision)
+}
+
+// SamplingStats reports sampler counters since sampling was enabled
+type SamplingStats struct {
+	Sampled uint64
+	Dropped uint64
+}
+
+// Number of counters per level - messages hashing to the same slot share a counter
+const samplerBuckets = 4096
+
+// samplerCounter counts entries for one level/message slot in the current window
+type samplerCounter struct {
+	resetAt atomic.Int64
+	count   atomic.Uint64
+}
+
+// sampler implements first-N-then-every-Mth sampling without allocations
+type sampler struct {
+	tick       int64
+	first      uint64
+	thereafter uint64
+	hook       func(level LogLevel, message string, decision SamplingDecision)
+
+	counters [ERROR + 1][samplerBuckets]samplerCounter
+
+	sampled atomic.Uint64
+	dropped atomic.Uint64
+}
+
+// newSampler builds a sampler from its configuration
+func newSampler(config SamplingConfig) *sampler {
+	if config.Tick <= 0 {
+		config.Tick = time.Second
+	}
+	if config.First < 0 {
+		config.First = 0
+	}
+	if config.Thereafter < 0 {
+		config.Thereafter = 0
+	}
+
+	return &sampler{
+		tick:       int64(config.Tick),
+		first:      uint64(config.First),
+		thereafter: uint64(config.Thereafter),
+		hook:       config.Hook,
+	}
+}
+
+// check reports whether an entry logged at now (Unix nanoseconds) should be logged
+func (s *sampler) check(level LogLevel, message string, now int64) bool {
+	if level < DEBUG || level > ERROR {
+		return true
+	}
+
+	counter := &s.counters[level][fnv32a(message)%samplerBuckets]
+	n := counter.incr(now, s.tick)
+
+	if n <= s.first || (s.thereafter > 0 && (n-s.first)%s.thereafter == 0) {
+		s.sampled.Add(1)
+		if s.hook != nil {
+			s.hook(level, message, LogSampled)
+		}
+		return true
+	}
+
+	s.dropped.Add(1)
+	if s.hook != nil {
+		s.hook(level, message, LogDropped)
+	}
+	return false
+}
+
+// incr counts an entry, starting a new window once the current one expires
+func (c *samplerCounter) incr(now, tick int64) uint64 {
+	resetAt := c.resetAt.Load()
+	if resetAt > now {
+		return c.count.Add(1)
+	}
+
+	// Window expired - one goroutine wins the reset, the others just count
+	if c.resetAt.CompareAndSwap(resetAt, now+tick) {
+		c.count.Store(1)
+		return 1
+	}
+	return c.count.Add(1)
+}
+
+// fnv32a hashes a message without allocating
+func fnv32a(s string) uint32 {
+	const (
+		offset32 = 2166136261
+		prime32  = 16777619
+	)
+
+	hash := uint32(offset32)
+	for i := 0; i < len(s); i++ {
+		hash ^= uint32(s[i])
+		hash *= prime32
+	}
+	return hash
+}
+
+// sample reports whether the sampler (if any) lets this entry through
+func (l *Logger) sample(level LogLevel, message string) bool {
+	return l.sampler == nil || l.sampler.check(level, message, l.now().UnixNano())
+}
+
+// SetSampling enables per-message sampling on the default logger
+func SetSampling(config SamplingConfig) {
+	if defaultLogger != nil {
+		defaultLogger.sampler = newSampler(config)
+	}
+}
+
+// DisableSampling turns sampling off for the default logger
+func DisableSampling() {
+	if defaultLogger != nil {
+		defaultLogger.sampler = nil
+	}
+}
+
+// GetSamplingStats returns the default logger's sampled and dropped counts
+func GetSamplingStats() SamplingStats {
+	if defaultLogger == nil || defaultLogger.sampler == nil {
+		return SamplingStats{}
+	}
+	return SamplingStats{
+		Sampled: defaultLogger.sampler.sampled.Load(),
+		Dropped: defaultLogger.sampler.dropped.Load(),
+	}
+}
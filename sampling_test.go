@@ -0,0 +1,76 @@
+package emit
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestSampling tests first-N-then-every-Mth sampling across API styles
+func TestSampling(t *testing.T) {
+	var buf bytes.Buffer
+
+	decisions := map[SamplingDecision]int{}
+	testLogger := newTestLogger(&buf, withSampling(SamplingConfig{
+		Tick:       time.Hour,
+		First:      3,
+		Thereafter: 5,
+		Hook: func(level LogLevel, message string, decision SamplingDecision) {
+			decisions[decision]++
+		},
+	}))
+
+	// Entries 1-3 are logged, then 8, 13 and 18
+	for i := 0; i < 20; i++ {
+		testLogger.logStructuredFields(INFO, "hot path", ZInt("i", i))
+	}
+	if count := strings.Count(buf.String(), "hot path"); count != 6 {
+		t.Errorf("Expected 6 sampled structured entries, got %d", count)
+	}
+
+	// Counters are kept per level and message
+	buf.Reset()
+	for i := 0; i < 4; i++ {
+		testLogger.log(INFO, "other message", map[string]any{"i": i})
+		testLogger.log(WARN, "hot path", nil)
+	}
+	if count := strings.Count(buf.String(), "other message"); count != 3 {
+		t.Errorf("Expected 3 sampled map entries, got %d", count)
+	}
+	if count := strings.Count(buf.String(), `"level":"warn"`); count != 3 {
+		t.Errorf("Expected 3 sampled warn entries, got %d", count)
+	}
+
+	if decisions[LogSampled] != 12 || decisions[LogDropped] != 16 {
+		t.Errorf("Unexpected hook decisions: %v", decisions)
+	}
+	if sampled, dropped := testLogger.sampler.sampled.Load(), testLogger.sampler.dropped.Load(); sampled != 12 || dropped != 16 {
+		t.Errorf("Unexpected sampler counters: sampled=%d dropped=%d", sampled, dropped)
+	}
+}
+
+// TestSamplingWindowReset tests that counters restart every tick
+func TestSamplingWindowReset(t *testing.T) {
+	s := newSampler(SamplingConfig{Tick: 20 * time.Millisecond, First: 1})
+	now := time.Now().UnixNano()
+
+	if !s.check(INFO, "tick", now) || s.check(INFO, "tick", now+int64(19*time.Millisecond)) {
+		t.Fatal("Expected only the first entry in the window to be sampled")
+	}
+
+	if !s.check(INFO, "tick", now+int64(20*time.Millisecond)) {
+		t.Error("Expected the first entry of a new window to be sampled")
+	}
+}
+
+// BenchmarkSampledStructuredFields checks the sampler keeps the structured path allocation-free
+func BenchmarkSampledStructuredFields(b *testing.B) {
+	testLogger := newTestLogger(io.Discard, withSampling(SamplingConfig{First: 100, Thereafter: 100}))
+
+	b.ReportAllocs()
+	for b.Loop() {
+		testLogger.logStructuredFields(INFO, "sampled", ZString("k", "v"), ZInt("n", 1))
+	}
+}
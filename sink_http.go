@@ -0,0 +1,729 @@
+package emit
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// HTTPEntry is one encoded log entry waiting to be shipped
+type HTTPEntry struct {
+	Time time.Time
+	Data []byte // encoded entry without the trailing newline
+}
+
+// HTTPBodyBuilder turns a batch of entries into a request body
+type HTTPBodyBuilder interface {
+	ContentType() string
+	Build(buf *bytes.Buffer, entries []HTTPEntry) error
+}
+
+// HTTPSinkConfig configures an HTTPSink
+type HTTPSinkConfig struct {
+	// URL is the endpoint batches are POSTed to
+	URL string
+
+	// Body builds request bodies (default: NDJSONBody())
+	Body HTTPBodyBuilder
+
+	// Headers are added to every request (e.g. Authorization)
+	Headers map[string]string
+
+	// Client sends the requests (default: client with a 10s timeout)
+	Client *http.Client
+
+	// BatchSize and BatchBytes trigger a flush when either is reached
+	// (defaults: 500 entries, 1 MiB)
+	BatchSize  int
+	BatchBytes int
+
+	// FlushInterval is the longest an entry waits before being shipped (default: 1s)
+	FlushInterval time.Duration
+
+	// MaxPending bounds entries held in memory while the endpoint is slow;
+	// beyond it entries are spilled to disk or dropped (default: 10 x BatchSize)
+	MaxPending int
+
+	// MaxRetries is the number of retries after the first attempt
+	// (default: 5, negative disables retries)
+	MaxRetries int
+
+	// InitialBackoff and MaxBackoff bound the jittered exponential backoff
+	// between retries (defaults: 100ms, 10s)
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+
+	// DisableCompression sends bodies without gzip
+	DisableCompression bool
+
+	// SpillDir enables the local disk buffer used while the endpoint is down
+	SpillDir string
+
+	// MaxSpillBytes caps the disk buffer, dropping the oldest batches first
+	// (default: 256 MiB)
+	MaxSpillBytes int64
+
+	// OnError is called when a batch cannot be delivered
+	OnError func(error)
+
+	// Clock stamps entries and times flushes, spills and retries
+	// (default: the system clock)
+	Clock Clock
+}
+
+// HTTPSinkStats reports delivery counters for an HTTPSink
+type HTTPSinkStats struct {
+	Sent    int64 // entries accepted by the endpoint
+	Spilled int64 // entries written to the disk buffer
+	Dropped int64 // entries lost (rejected, or no disk buffer available)
+	Retries int64 // request retries
+}
+
+// HTTPSink batches encoded entries and ships them to an HTTP endpoint.
+// Use it as the logger output with SetOutput(sink) and Close it on shutdown.
+type HTTPSink struct {
+	config HTTPSinkConfig
+
+	mu           sync.Mutex
+	pending      []HTTPEntry
+	pendingBytes int
+	closed       bool
+
+	spillMu   sync.Mutex
+	spillSeq  int64
+	replaying string // Spill file being replayed, kept by enforceSpillLimit
+	hasSpill  atomic.Bool
+
+	flushCh chan struct{}
+	done    chan struct{}
+	wg      sync.WaitGroup
+
+	sent    atomic.Int64
+	spilled atomic.Int64
+	dropped atomic.Int64
+	retries atomic.Int64
+}
+
+// Wraps failures that will not succeed on retry
+type permanentHTTPError struct {
+	err error
+}
+
+func (e *permanentHTTPError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentHTTPError) Unwrap() error {
+	return e.err
+}
+
+// Returned when the sink is closed while a batch is being retried
+var errHTTPSinkClosed = errors.New("emit: http sink closed")
+
+// NewHTTPSink creates a batching HTTP sink and starts its background sender
+func NewHTTPSink(config HTTPSinkConfig) (*HTTPSink, error) {
+	if config.URL == "" {
+		return nil, errors.New("emit: http sink requires a URL")
+	}
+	if config.Body == nil {
+		config.Body = NDJSONBody()
+	}
+	if config.Client == nil {
+		config.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 500
+	}
+	if config.BatchBytes <= 0 {
+		config.BatchBytes = 1 << 20
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = time.Second
+	}
+	if config.MaxPending <= 0 {
+		config.MaxPending = 10 * config.BatchSize
+	}
+	if config.MaxRetries < 0 {
+		config.MaxRetries = 0
+	} else if config.MaxRetries == 0 {
+		config.MaxRetries = 5
+	}
+	if config.InitialBackoff <= 0 {
+		config.InitialBackoff = 100 * time.Millisecond
+	}
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = 10 * time.Second
+	}
+	if config.MaxSpillBytes <= 0 {
+		config.MaxSpillBytes = 256 << 20
+	}
+	if config.Clock == nil {
+		config.Clock = systemClock{}
+	}
+
+	if config.SpillDir != "" {
+		if err := os.MkdirAll(config.SpillDir, 0o755); err != nil {
+			return nil, err
+		}
+	}
+
+	sink := &HTTPSink{
+		config:  config,
+		flushCh: make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+
+	// Batches spilled before a restart are replayed on the first flush
+	if config.SpillDir != "" && len(sink.spillFiles()) > 0 {
+		sink.hasSpill.Store(true)
+	}
+
+	sink.wg.Add(1)
+	go sink.run()
+
+	return sink, nil
+}
+
+// Write queues one encoded entry; it never blocks on the network
+func (s *HTTPSink) Write(p []byte) (int, error) {
+	entry := HTTPEntry{
+		Time: s.config.Clock.Now(),
+		Data: append([]byte(nil), bytes.TrimRight(p, "\n")...),
+	}
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		s.dropped.Add(1)
+		return 0, errHTTPSinkClosed
+	}
+
+	s.pending = append(s.pending, entry)
+	s.pendingBytes += len(entry.Data)
+
+	var overflow []HTTPEntry
+	if len(s.pending) > s.config.MaxPending {
+		// The sender is stuck retrying - move the backlog out of memory
+		overflow = s.pending
+		s.pending = nil
+		s.pendingBytes = 0
+	}
+	full := len(s.pending) >= s.config.BatchSize || s.pendingBytes >= s.config.BatchBytes
+	s.mu.Unlock()
+
+	if overflow != nil {
+		s.spill(overflow)
+	}
+	if full {
+		s.requestFlush()
+	}
+
+	return len(p), nil
+}
+
+// Flush requests an immediate delivery of queued entries
+func (s *HTTPSink) Flush() {
+	s.requestFlush()
+}
+
+// Close delivers or spills queued entries and stops the background sender
+func (s *HTTPSink) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	s.mu.Unlock()
+
+	close(s.done)
+	s.wg.Wait()
+	return nil
+}
+
+// Stats returns a snapshot of the delivery counters
+func (s *HTTPSink) Stats() HTTPSinkStats {
+	return HTTPSinkStats{
+		Sent:    s.sent.Load(),
+		Spilled: s.spilled.Load(),
+		Dropped: s.dropped.Load(),
+		Retries: s.retries.Load(),
+	}
+}
+
+// requestFlush wakes the sender without blocking
+func (s *HTTPSink) requestFlush() {
+	select {
+	case s.flushCh <- struct{}{}:
+	default:
+	}
+}
+
+// run is the background sender loop
+func (s *HTTPSink) run() {
+	defer s.wg.Done()
+
+	ticker := s.config.Clock.NewTicker(s.config.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C():
+			s.flush(false)
+		case <-s.flushCh:
+			s.flush(false)
+		case <-s.done:
+			// Final attempt - anything undeliverable goes to disk
+			s.flush(true)
+			return
+		}
+	}
+}
+
+// flush ships queued entries in batches. Batches previously spilled to
+// disk are replayed first so the endpoint receives entries in order.
+func (s *HTTPSink) flush(final bool) {
+	s.mu.Lock()
+	queued := s.pending
+	s.pending = nil
+	s.pendingBytes = 0
+	s.mu.Unlock()
+
+	if s.hasSpill.Load() && (final || !s.replaySpill()) {
+		// Endpoint still down, or shutting down - queue behind the spilled batches
+		if len(queued) > 0 {
+			s.spill(queued)
+		}
+		return
+	}
+
+	for len(queued) > 0 {
+		batch := s.nextBatch(queued)
+		queued = queued[len(batch):]
+
+		if err := s.deliver(batch); err != nil {
+			s.reportError(err)
+
+			var permanent *permanentHTTPError
+			if errors.As(err, &permanent) {
+				s.dropped.Add(int64(len(batch)))
+				continue
+			}
+
+			// Endpoint is down - keep the rest for later
+			s.spill(batch)
+			if len(queued) > 0 {
+				s.spill(queued)
+			}
+			return
+		}
+	}
+}
+
+// nextBatch returns the longest prefix within the batch limits
+func (s *HTTPSink) nextBatch(entries []HTTPEntry) []HTTPEntry {
+	size := 0
+	for i, entry := range entries {
+		size += len(entry.Data)
+		if i+1 >= s.config.BatchSize || size >= s.config.BatchBytes {
+			return entries[:i+1]
+		}
+	}
+	return entries
+}
+
+// deliver sends one batch, retrying transient failures with jittered
+// exponential backoff
+func (s *HTTPSink) deliver(batch []HTTPEntry) error {
+	var body bytes.Buffer
+	if err := s.config.Body.Build(&body, batch); err != nil {
+		return &permanentHTTPError{err: err}
+	}
+
+	payload := body.Bytes()
+	if !s.config.DisableCompression {
+		var compressed bytes.Buffer
+		gz := gzip.NewWriter(&compressed)
+		_, _ = gz.Write(payload)
+		_ = gz.Close()
+		payload = compressed.Bytes()
+	}
+
+	var err error
+	for attempt := 0; attempt <= s.config.MaxRetries; attempt++ {
+		if attempt > 0 {
+			s.retries.Add(1)
+			if !s.wait(s.backoff(attempt)) {
+				return errHTTPSinkClosed
+			}
+		}
+
+		if err = s.post(payload); err == nil {
+			s.sent.Add(int64(len(batch)))
+			return nil
+		}
+
+		var permanent *permanentHTTPError
+		if errors.As(err, &permanent) {
+			return err
+		}
+	}
+
+	return err
+}
+
+// wait sleeps on the sink's clock, reporting false if the sink closes first
+func (s *HTTPSink) wait(delay time.Duration) bool {
+	timer := s.config.Clock.NewTicker(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C():
+		return true
+	case <-s.done:
+		return false
+	}
+}
+
+// backoff returns a full-jitter delay for the given retry attempt
+func (s *HTTPSink) backoff(attempt int) time.Duration {
+	delay := s.config.InitialBackoff << (attempt - 1)
+	if delay <= 0 || delay > s.config.MaxBackoff {
+		delay = s.config.MaxBackoff
+	}
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// post performs a single request
+func (s *HTTPSink) post(payload []byte) error {
+	req, err := http.NewRequest(http.MethodPost, s.config.URL, bytes.NewReader(payload))
+	if err != nil {
+		return &permanentHTTPError{err: err}
+	}
+
+	req.Header.Set("Content-Type", s.config.Body.ContentType())
+	if !s.config.DisableCompression {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	for key, value := range s.config.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := s.config.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return fmt.Errorf("emit: endpoint returned status %d", resp.StatusCode)
+	default:
+		return &permanentHTTPError{err: fmt.Errorf("emit: endpoint rejected batch with status %d", resp.StatusCode)}
+	}
+}
+
+// reportError forwards delivery errors to the configured callback
+func (s *HTTPSink) reportError(err error) {
+	if s.config.OnError != nil {
+		s.config.OnError(err)
+	}
+}
+
+// Spill files hold length-prefixed records: 8-byte unix nanos, 4-byte size, data
+const spillFilePrefix = "emit-spill-"
+
+// spill writes entries to the disk buffer, or drops them without one
+func (s *HTTPSink) spill(entries []HTTPEntry) {
+	if s.config.SpillDir == "" {
+		s.dropped.Add(int64(len(entries)))
+		return
+	}
+
+	s.spillMu.Lock()
+	defer s.spillMu.Unlock()
+
+	// Names sort in write order, including across restarts
+	seq := s.config.Clock.Now().UnixNano()
+	if seq <= s.spillSeq {
+		seq = s.spillSeq + 1
+	}
+	s.spillSeq = seq
+
+	name := filepath.Join(s.config.SpillDir, fmt.Sprintf("%s%020d.bin", spillFilePrefix, seq))
+	if err := os.WriteFile(name, encodeSpillRecords(entries), 0o644); err != nil {
+		s.reportError(err)
+		s.dropped.Add(int64(len(entries)))
+		return
+	}
+	s.spilled.Add(int64(len(entries)))
+	s.hasSpill.Store(true)
+
+	s.enforceSpillLimit()
+}
+
+// spillFiles lists disk buffer files oldest first
+func (s *HTTPSink) spillFiles() []string {
+	entries, err := os.ReadDir(s.config.SpillDir)
+	if err != nil {
+		return nil
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasPrefix(entry.Name(), spillFilePrefix) {
+			names = append(names, filepath.Join(s.config.SpillDir, entry.Name()))
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// enforceSpillLimit removes the oldest spill files beyond MaxSpillBytes,
+// except the one being replayed. Called with spillMu held.
+func (s *HTTPSink) enforceSpillLimit() {
+	files := s.spillFiles()
+
+	var total int64
+	sizes := make([]int64, len(files))
+	for i, name := range files {
+		if info, err := os.Stat(name); err == nil {
+			sizes[i] = info.Size()
+			total += sizes[i]
+		}
+	}
+
+	for i := 0; i < len(files) && total > s.config.MaxSpillBytes; i++ {
+		if files[i] == s.replaying {
+			continue
+		}
+		if entries, err := readSpillFile(files[i]); err == nil {
+			s.dropped.Add(int64(len(entries)))
+		}
+		_ = os.Remove(files[i])
+		total -= sizes[i]
+	}
+}
+
+// replaySpill re-sends spilled batches oldest first, stopping at the first
+// failure. After each acknowledged batch the file is rewritten with the
+// entries left, so a failure or restart never re-sends delivered batches.
+// It reports whether the disk buffer was fully drained.
+func (s *HTTPSink) replaySpill() bool {
+	s.spillMu.Lock()
+	files := s.spillFiles()
+	if len(files) == 0 {
+		s.hasSpill.Store(false)
+	}
+	s.spillMu.Unlock()
+
+	defer s.setReplaying("")
+	for _, name := range files {
+		// Files dropped by the spill limit since they were listed are gone
+		if !s.setReplaying(name) {
+			continue
+		}
+
+		entries, err := readSpillFile(name)
+		if err != nil {
+			// Unreadable or truncated file - nothing more can be recovered
+			s.reportError(err)
+			_ = os.Remove(name)
+			continue
+		}
+
+		for len(entries) > 0 {
+			batch := s.nextBatch(entries)
+			if err := s.deliver(batch); err != nil {
+				var permanent *permanentHTTPError
+				if !errors.As(err, &permanent) {
+					s.reportError(err)
+					return false
+				}
+				s.dropped.Add(int64(len(batch)))
+			}
+			entries = entries[len(batch):]
+
+			if len(entries) > 0 {
+				if err := s.rewriteSpillFile(name, entries); err != nil {
+					s.reportError(err)
+				}
+			}
+		}
+
+		_ = os.Remove(name)
+	}
+
+	return true
+}
+
+// setReplaying marks the spill file being replayed, reporting false if it
+// no longer exists
+func (s *HTTPSink) setReplaying(name string) bool {
+	s.spillMu.Lock()
+	defer s.spillMu.Unlock()
+
+	s.replaying = ""
+	if name == "" {
+		return true
+	}
+	if _, err := os.Stat(name); err != nil {
+		return false
+	}
+	s.replaying = name
+	return true
+}
+
+// rewriteSpillFile replaces a spill file with the entries still to be
+// delivered, through a temporary file so a crash leaves one or the other
+func (s *HTTPSink) rewriteSpillFile(name string, entries []HTTPEntry) error {
+	s.spillMu.Lock()
+	defer s.spillMu.Unlock()
+
+	// The temporary name lacks the spill prefix so it is never replayed
+	tmp := filepath.Join(filepath.Dir(name), ".tmp-"+filepath.Base(name))
+	if err := os.WriteFile(tmp, encodeSpillRecords(entries), 0o644); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, name)
+}
+
+// encodeSpillRecords encodes entries as spill file records
+func encodeSpillRecords(entries []HTTPEntry) []byte {
+	var buf bytes.Buffer
+	var header [12]byte
+	for _, entry := range entries {
+		binary.LittleEndian.PutUint64(header[:8], uint64(entry.Time.UnixNano()))
+		binary.LittleEndian.PutUint32(header[8:], uint32(len(entry.Data)))
+		buf.Write(header[:])
+		buf.Write(entry.Data)
+	}
+	return buf.Bytes()
+}
+
+// readSpillFile decodes the records of one spill file
+func readSpillFile(name string) ([]HTTPEntry, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []HTTPEntry
+	for len(data) > 0 {
+		if len(data) < 12 {
+			return entries, io.ErrUnexpectedEOF
+		}
+		nanos := int64(binary.LittleEndian.Uint64(data[:8]))
+		size := int(binary.LittleEndian.Uint32(data[8:12]))
+		if len(data) < 12+size {
+			return entries, io.ErrUnexpectedEOF
+		}
+		entries = append(entries, HTTPEntry{
+			Time: time.Unix(0, nanos),
+			Data: data[12 : 12+size],
+		})
+		data = data[12+size:]
+	}
+	return entries, nil
+}
+
+// ndjsonBody writes one entry per line
+type ndjsonBody struct{}
+
+// NDJSONBody sends entries as newline-delimited JSON
+func NDJSONBody() HTTPBodyBuilder {
+	return ndjsonBody{}
+}
+
+func (ndjsonBody) ContentType() string {
+	return "application/x-ndjson"
+}
+
+func (ndjsonBody) Build(buf *bytes.Buffer, entries []HTTPEntry) error {
+	for _, entry := range entries {
+		buf.Write(entry.Data)
+		buf.WriteByte('\n')
+	}
+	return nil
+}
+
+// elasticsearchBulkBody writes Elasticsearch _bulk create actions
+type elasticsearchBulkBody struct {
+	action []byte
+}
+
+// ElasticsearchBulkBody sends entries as _bulk create actions into index
+// (which may be a data stream)
+func ElasticsearchBulkBody(index string) HTTPBodyBuilder {
+	action, _ := json.Marshal(map[string]map[string]string{
+		"create": {"_index": index},
+	})
+	return elasticsearchBulkBody{action: action}
+}
+
+func (elasticsearchBulkBody) ContentType() string {
+	return "application/x-ndjson"
+}
+
+func (b elasticsearchBulkBody) Build(buf *bytes.Buffer, entries []HTTPEntry) error {
+	for _, entry := range entries {
+		buf.Write(b.action)
+		buf.WriteByte('\n')
+		buf.Write(entry.Data)
+		buf.WriteByte('\n')
+	}
+	return nil
+}
+
+// lokiBody writes a Loki push API request with a single stream
+type lokiBody struct {
+	labels map[string]string
+}
+
+// LokiBody sends entries to the Loki push API (/loki/api/v1/push) as one
+// stream identified by labels
+func LokiBody(labels map[string]string) HTTPBodyBuilder {
+	if len(labels) == 0 {
+		labels = map[string]string{"job": "emit"}
+	}
+	return lokiBody{labels: labels}
+}
+
+func (lokiBody) ContentType() string {
+	return "application/json"
+}
+
+func (b lokiBody) Build(buf *bytes.Buffer, entries []HTTPEntry) error {
+	type lokiStream struct {
+		Stream map[string]string `json:"stream"`
+		Values [][2]string       `json:"values"`
+	}
+
+	stream := lokiStream{
+		Stream: b.labels,
+		Values: make([][2]string, len(entries)),
+	}
+	for i, entry := range entries {
+		stream.Values[i] = [2]string{strconv.FormatInt(entry.Time.UnixNano(), 10), string(entry.Data)}
+	}
+
+	return json.NewEncoder(buf).Encode(map[string][]lokiStream{"streams": {stream}})
+}
@@ -0,0 +1,358 @@
+package emit
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cloudresty/emit/emittest"
+)
+
+// fakeLogEndpoint records decompressed request bodies
+type fakeLogEndpoint struct {
+	mu       sync.Mutex
+	bodies   []string
+	failures atomic.Int32 // requests to reject with 503 before accepting
+}
+
+func (f *fakeLogEndpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if f.failures.Load() > 0 {
+		f.failures.Add(-1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	var body io.Reader = r.Body
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		body = gz
+	}
+
+	data, _ := io.ReadAll(body)
+	f.mu.Lock()
+	f.bodies = append(f.bodies, string(data))
+	f.mu.Unlock()
+}
+
+func (f *fakeLogEndpoint) lines() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var lines []string
+	for _, body := range f.bodies {
+		scanner := bufio.NewScanner(strings.NewReader(body))
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+	}
+	return lines
+}
+
+// TestHTTPSinkNDJSON tests batching and gzip delivery through the logger
+func TestHTTPSinkNDJSON(t *testing.T) {
+	endpoint := &fakeLogEndpoint{}
+	server := httptest.NewServer(endpoint)
+	defer server.Close()
+
+	sink, err := NewHTTPSink(HTTPSinkConfig{URL: server.URL, BatchSize: 2, FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("Failed to create http sink: %v", err)
+	}
+
+	testLogger := newTestLogger(sink)
+
+	testLogger.log(INFO, "first", nil)
+	testLogger.logStructuredFields(INFO, "second", ZInt("n", 2))
+	testLogger.log(WARN, "third", map[string]any{"k": "v"})
+
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	lines := endpoint.lines()
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 delivered entries, got %d: %v", len(lines), lines)
+	}
+	for i, message := range []string{"first", "second", "third"} {
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(lines[i]), &entry); err != nil {
+			t.Fatalf("Delivered entry is not valid JSON: %q", lines[i])
+		}
+		if entry["message"] != message {
+			t.Errorf("Expected entry %d to be %q, got %v", i, message, entry["message"])
+		}
+	}
+
+	if stats := sink.Stats(); stats.Sent != 3 || stats.Dropped != 0 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
+
+// TestHTTPSinkRetry tests retrying transient endpoint failures
+func TestHTTPSinkRetry(t *testing.T) {
+	endpoint := &fakeLogEndpoint{}
+	endpoint.failures.Store(2)
+	server := httptest.NewServer(endpoint)
+	defer server.Close()
+
+	sink, err := NewHTTPSink(HTTPSinkConfig{
+		URL:            server.URL,
+		FlushInterval:  time.Hour,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     5 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create http sink: %v", err)
+	}
+
+	_, _ = sink.Write([]byte(`{"message":"retried"}` + "\n"))
+	sink.Flush()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for sink.Stats().Sent == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	_ = sink.Close()
+
+	if stats := sink.Stats(); stats.Sent != 1 || stats.Retries != 2 {
+		t.Errorf("Expected 1 entry sent after 2 retries, got %+v", stats)
+	}
+}
+
+// TestHTTPSinkSpill tests the disk buffer while the endpoint is down
+func TestHTTPSinkSpill(t *testing.T) {
+	spillDir := t.TempDir()
+
+	endpoint := &fakeLogEndpoint{}
+	endpoint.failures.Store(1 << 20)
+	server := httptest.NewServer(endpoint)
+	defer server.Close()
+
+	config := HTTPSinkConfig{
+		URL:           server.URL,
+		FlushInterval: time.Hour,
+		MaxRetries:    -1,
+		SpillDir:      spillDir,
+	}
+
+	sink, err := NewHTTPSink(config)
+	if err != nil {
+		t.Fatalf("Failed to create http sink: %v", err)
+	}
+	_, _ = sink.Write([]byte(`{"message":"buffered-1"}` + "\n"))
+	_, _ = sink.Write([]byte(`{"message":"buffered-2"}` + "\n"))
+	_ = sink.Close()
+
+	if stats := sink.Stats(); stats.Spilled != 2 || stats.Sent != 0 {
+		t.Fatalf("Expected 2 spilled entries, got %+v", stats)
+	}
+
+	// Endpoint recovers - a new sink replays the buffer before new entries
+	endpoint.failures.Store(0)
+
+	sink, err = NewHTTPSink(config)
+	if err != nil {
+		t.Fatalf("Failed to create http sink: %v", err)
+	}
+	_, _ = sink.Write([]byte(`{"message":"fresh"}` + "\n"))
+	sink.Flush()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for sink.Stats().Sent < 3 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	_ = sink.Close()
+
+	lines := endpoint.lines()
+	expected := []string{"buffered-1", "buffered-2", "fresh"}
+	if len(lines) != len(expected) {
+		t.Fatalf("Expected %d delivered entries, got %v", len(expected), lines)
+	}
+	for i, message := range expected {
+		if !strings.Contains(lines[i], message) {
+			t.Errorf("Expected entry %d to be %q, got %q", i, message, lines[i])
+		}
+	}
+}
+
+// TestHTTPSinkSpillPartialReplay tests that a replay failing halfway
+// through a spill file does not re-send the batches already delivered
+func TestHTTPSinkSpillPartialReplay(t *testing.T) {
+	endpoint := &fakeLogEndpoint{}
+	var requests atomic.Int32
+	var down atomic.Bool
+	down.Store(true)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Accept the first batch, then fail until the endpoint recovers
+		if requests.Add(1) > 1 && down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		endpoint.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	sink, err := NewHTTPSink(HTTPSinkConfig{
+		URL:           server.URL,
+		BatchSize:     1,
+		FlushInterval: time.Hour,
+		MaxRetries:    -1,
+		SpillDir:      t.TempDir(),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create http sink: %v", err)
+	}
+	defer sink.Close()
+
+	sink.spill([]HTTPEntry{
+		{Time: time.Unix(1, 0), Data: []byte(`{"message":"one"}`)},
+		{Time: time.Unix(2, 0), Data: []byte(`{"message":"two"}`)},
+		{Time: time.Unix(3, 0), Data: []byte(`{"message":"three"}`)},
+	})
+	if sink.replaySpill() {
+		t.Fatal("Expected the replay to stop at the failing batch")
+	}
+	files := sink.spillFiles()
+	if len(files) != 1 {
+		t.Fatalf("Expected the spill file to remain, got %v", files)
+	}
+	if entries, err := readSpillFile(files[0]); err != nil || len(entries) != 2 {
+		t.Fatalf("Expected the 2 undelivered entries left, got %d (%v)", len(entries), err)
+	}
+
+	down.Store(false)
+	if !sink.replaySpill() {
+		t.Fatal("Expected the replay to drain the spill file")
+	}
+	lines := endpoint.lines()
+	expected := []string{"one", "two", "three"}
+	if len(lines) != len(expected) {
+		t.Fatalf("Expected each entry delivered once, got %v", lines)
+	}
+	for i, message := range expected {
+		if !strings.Contains(lines[i], message) {
+			t.Errorf("Expected entry %d to be %q, got %q", i, message, lines[i])
+		}
+	}
+}
+
+// TestHTTPSinkSpillLimitKeepsReplayingFile tests that the spill limit never
+// removes the file being replayed, which the replay would then rewrite
+func TestHTTPSinkSpillLimitKeepsReplayingFile(t *testing.T) {
+	sink, err := NewHTTPSink(HTTPSinkConfig{URL: "http://127.0.0.1:0", FlushInterval: time.Hour, SpillDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("Failed to create http sink: %v", err)
+	}
+	defer sink.Close()
+
+	sink.spill([]HTTPEntry{{Time: time.Unix(1, 0), Data: []byte(`{"message":"replaying"}`)}})
+	replaying := sink.spillFiles()[0]
+	if !sink.setReplaying(replaying) {
+		t.Fatal("Expected the spill file to exist")
+	}
+
+	sink.config.MaxSpillBytes = 1
+	sink.spill([]HTTPEntry{{Time: time.Unix(2, 0), Data: []byte(`{"message":"newer"}`)}})
+
+	files := sink.spillFiles()
+	if len(files) != 1 || files[0] != replaying {
+		t.Fatalf("Expected only the file being replayed to remain, got %v", files)
+	}
+
+	// Once the replay moves on, the file is subject to the limit again
+	sink.setReplaying("")
+	sink.spillMu.Lock()
+	sink.enforceSpillLimit()
+	sink.spillMu.Unlock()
+	if files := sink.spillFiles(); len(files) != 0 {
+		t.Fatalf("Expected the spill limit to remove the file, got %v", files)
+	}
+}
+
+// TestHTTPSinkClock tests that entries are stamped and spilled with the
+// configured clock
+func TestHTTPSinkClock(t *testing.T) {
+	endpoint := &fakeLogEndpoint{}
+	server := httptest.NewServer(endpoint)
+	defer server.Close()
+
+	clock := emittest.NewClock(time.Unix(1700000000, 0))
+	spillDir := t.TempDir()
+	sink, err := NewHTTPSink(HTTPSinkConfig{
+		URL:           server.URL,
+		Body:          LokiBody(map[string]string{"app": "api"}),
+		FlushInterval: time.Hour,
+		SpillDir:      spillDir,
+		Clock:         clock,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create http sink: %v", err)
+	}
+
+	_, _ = sink.Write([]byte(`{"message":"stamped"}` + "\n"))
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	lines := endpoint.lines()
+	if len(lines) != 1 || !strings.Contains(lines[0], `"1700000000000000000"`) {
+		t.Fatalf("Expected the entry stamped with the fake clock, got %v", lines)
+	}
+
+	sink.spill([]HTTPEntry{{Time: clock.Now(), Data: []byte(`{"message":"spilled"}`)}})
+	if files := sink.spillFiles(); len(files) != 1 || !strings.Contains(files[0], "1700000000000000000") {
+		t.Fatalf("Expected the spill file named from the fake clock, got %v", files)
+	}
+}
+
+// TestHTTPBodyBuilders tests the Loki and Elasticsearch body formats
+func TestHTTPBodyBuilders(t *testing.T) {
+	entries := []HTTPEntry{
+		{Time: time.Unix(0, 1700000000000000001), Data: []byte(`{"message":"a"}`)},
+		{Time: time.Unix(0, 1700000000000000002), Data: []byte(`{"message":"b"}`)},
+	}
+
+	var buf bytes.Buffer
+	if err := LokiBody(map[string]string{"app": "api"}).Build(&buf, entries); err != nil {
+		t.Fatalf("Loki body failed: %v", err)
+	}
+
+	var push struct {
+		Streams []struct {
+			Stream map[string]string `json:"stream"`
+			Values [][2]string       `json:"values"`
+		} `json:"streams"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &push); err != nil {
+		t.Fatalf("Loki body is not valid JSON: %v", err)
+	}
+	if len(push.Streams) != 1 || push.Streams[0].Stream["app"] != "api" || len(push.Streams[0].Values) != 2 {
+		t.Fatalf("Unexpected Loki body: %s", buf.String())
+	}
+	if push.Streams[0].Values[0] != [2]string{"1700000000000000001", `{"message":"a"}`} {
+		t.Errorf("Unexpected Loki value: %v", push.Streams[0].Values[0])
+	}
+
+	buf.Reset()
+	if err := ElasticsearchBulkBody("logs-app").Build(&buf, entries); err != nil {
+		t.Fatalf("Elasticsearch body failed: %v", err)
+	}
+	expected := `{"create":{"_index":"logs-app"}}` + "\n" + `{"message":"a"}` + "\n" +
+		`{"create":{"_index":"logs-app"}}` + "\n" + `{"message":"b"}` + "\n"
+	if buf.String() != expected {
+		t.Errorf("Unexpected bulk body:\n%s", buf.String())
+	}
+}
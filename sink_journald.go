@@ -0,0 +1,532 @@
+//go:build linux
+
+package emit
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"maps"
+	"net"
+	"os"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// Default journald native protocol socket
+const defaultJournaldSocket = "/run/systemd/journal/socket"
+
+// Entries larger than this are passed to journald through a file descriptor
+const defaultJournaldMaxDatagram = 128 * 1024
+
+// JournaldConfig configures a JournaldSink
+type JournaldConfig struct {
+	// SocketPath is the journald socket (default: /run/systemd/journal/socket)
+	SocketPath string
+
+	// Identifier is sent as SYSLOG_IDENTIFIER (default: the entry's component)
+	Identifier string
+
+	// MaxDatagramSize is the largest entry sent inline before falling back
+	// to passing a file descriptor (default: 128 KiB)
+	MaxDatagramSize int
+
+	// TempDir is where oversized entries are staged when the kernel cannot
+	// create sealed memory files (before Linux 3.17, or when memfd_create
+	// is filtered) (default: /dev/shm)
+	TempDir string
+}
+
+// JournaldSink writes log entries to journald using its native protocol.
+// Use it as the logger output with SetOutput(sink).
+//
+// Each JSON entry is translated into journal fields: message becomes MESSAGE,
+// level becomes PRIORITY, caller information becomes CODE_FILE, CODE_LINE and
+// CODE_FUNC, component becomes SYSLOG_IDENTIFIER and every other field is sent
+// as an uppercase user field. The keys are those of the default logger's JSON
+// layout (SetProfile, SetKeyMap or SetEncoderConfig); the ECS, OpenTelemetry
+// and GCP profiles translate their message, level and time only. Plain text
+// entries are sent as MESSAGE only.
+type JournaldSink struct {
+	config JournaldConfig
+	addr   *net.UnixAddr
+
+	mu   sync.Mutex
+	conn *net.UnixConn
+}
+
+// Journal fields that user fields are not allowed to overwrite
+var journaldReservedFields = map[string]bool{
+	"MESSAGE":           true,
+	"PRIORITY":          true,
+	"CODE_FILE":         true,
+	"CODE_LINE":         true,
+	"CODE_FUNC":         true,
+	"SYSLOG_IDENTIFIER": true,
+}
+
+// Pool of payload buffers for journald datagrams
+var journaldBufferPool = sync.Pool{
+	New: func() any {
+		return new(bytes.Buffer)
+	},
+}
+
+// NewJournaldSink creates a sink writing to the journald native socket
+func NewJournaldSink(config JournaldConfig) (*JournaldSink, error) {
+	if config.SocketPath == "" {
+		config.SocketPath = defaultJournaldSocket
+	}
+	if config.MaxDatagramSize <= 0 {
+		config.MaxDatagramSize = defaultJournaldMaxDatagram
+	}
+	if config.TempDir == "" {
+		config.TempDir = "/dev/shm"
+	}
+
+	sink := &JournaldSink{
+		config: config,
+		addr:   &net.UnixAddr{Name: config.SocketPath, Net: "unixgram"},
+	}
+
+	// Connect eagerly so a missing journald is reported at construction
+	if _, err := sink.connection(); err != nil {
+		return nil, err
+	}
+
+	return sink, nil
+}
+
+// JournaldAvailable reports whether the journald native socket exists
+func JournaldAvailable() bool {
+	info, err := os.Stat(defaultJournaldSocket)
+	return err == nil && info.Mode()&os.ModeSocket != 0
+}
+
+// Write translates one encoded log entry into journal fields and sends it
+func (s *JournaldSink) Write(p []byte) (int, error) {
+	buf := journaldBufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer journaldBufferPool.Put(buf)
+
+	s.encodeEntry(buf, bytes.TrimRight(p, "\n"))
+
+	if err := s.send(buf.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close closes the connection to journald
+func (s *JournaldSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+// connection returns the current socket, dialing it if needed
+func (s *JournaldSink) connection() (*net.UnixConn, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn != nil {
+		return s.conn, nil
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, s.addr)
+	if err != nil {
+		return nil, err
+	}
+	s.conn = conn
+	return conn, nil
+}
+
+// resetConnection drops a broken socket so the next send redials
+func (s *JournaldSink) resetConnection(conn *net.UnixConn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == conn {
+		_ = s.conn.Close()
+		s.conn = nil
+	}
+}
+
+// send delivers a payload, retrying once on a fresh connection
+func (s *JournaldSink) send(payload []byte) error {
+	err := s.sendOnce(payload)
+	if err == nil || errors.Is(err, syscall.EMSGSIZE) {
+		return err
+	}
+
+	// journald may have been restarted - redial and try again
+	return s.sendOnce(payload)
+}
+
+// sendOnce delivers a payload inline or through a file descriptor
+func (s *JournaldSink) sendOnce(payload []byte) error {
+	conn, err := s.connection()
+	if err != nil {
+		return err
+	}
+
+	if len(payload) <= s.config.MaxDatagramSize {
+		_, err = conn.Write(payload)
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, syscall.EMSGSIZE) && !errors.Is(err, syscall.ENOBUFS) {
+			s.resetConnection(conn)
+			return err
+		}
+	}
+
+	if err = s.sendLarge(conn, payload); err != nil && !errors.Is(err, syscall.EMSGSIZE) {
+		s.resetConnection(conn)
+	}
+	return err
+}
+
+// sendLarge writes the payload to a sealed memory file and passes its
+// descriptor to journald, the same fallback sd_journal_sendv uses when an
+// entry does not fit in a single datagram
+func (s *JournaldSink) sendLarge(conn *net.UnixConn, payload []byte) error {
+	file, err := sealedMemfd(payload)
+	if err != nil {
+		// No memfd_create - stage the payload in an unlinked file instead
+		if file, err = s.unlinkedTempFile(payload); err != nil {
+			return err
+		}
+	}
+	defer file.Close()
+
+	// net.UnixConn refuses WriteMsgUnix on a connected datagram socket,
+	// so the descriptor is sent with sendmsg on the raw socket
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return err
+	}
+
+	rights := syscall.UnixRights(int(file.Fd()))
+	var sendErr error
+	err = raw.Write(func(fd uintptr) bool {
+		sendErr = syscall.Sendmsg(int(fd), nil, rights, nil, 0)
+		return sendErr != syscall.EAGAIN
+	})
+	if err != nil {
+		return err
+	}
+	return sendErr
+}
+
+// memfd_create and file sealing constants missing from the syscall package
+const (
+	mfdCloexec      = 0x1
+	mfdAllowSealing = 0x2
+	fAddSeals       = 1033
+	fSealSeal       = 0x1
+	fSealShrink     = 0x2
+	fSealGrow       = 0x4
+	fSealWrite      = 0x8
+)
+
+// sysMemfdCreate is the memfd_create syscall number, which the syscall
+// package only defines on some architectures (0 where unknown)
+var sysMemfdCreate = map[string]uintptr{
+	"386": 356, "amd64": 319, "arm": 385, "arm64": 279, "loong64": 279,
+	"mips": 4354, "mipsle": 4354, "mips64": 5314, "mips64le": 5314,
+	"ppc64": 360, "ppc64le": 360, "riscv64": 279, "s390x": 350,
+}[runtime.GOARCH]
+
+// sealedMemfd returns an in-memory file holding payload, sealed so that
+// journald can read it without it changing underneath
+func sealedMemfd(payload []byte) (*os.File, error) {
+	if sysMemfdCreate == 0 {
+		return nil, syscall.ENOSYS
+	}
+
+	name, err := syscall.BytePtrFromString("emit-journal")
+	if err != nil {
+		return nil, err
+	}
+	fd, _, errno := syscall.Syscall(sysMemfdCreate, uintptr(unsafe.Pointer(name)), mfdCloexec|mfdAllowSealing, 0)
+	if errno != 0 {
+		return nil, errno
+	}
+	file := os.NewFile(fd, "emit-journal")
+
+	if _, err := file.Write(payload); err != nil {
+		file.Close()
+		return nil, err
+	}
+	seals := uintptr(fSealSeal | fSealShrink | fSealGrow | fSealWrite)
+	if _, _, errno := syscall.Syscall(syscall.SYS_FCNTL, fd, fAddSeals, seals); errno != 0 {
+		file.Close()
+		return nil, errno
+	}
+	return file, nil
+}
+
+// unlinkedTempFile writes payload to a file in TempDir that is removed
+// before its descriptor is passed on
+func (s *JournaldSink) unlinkedTempFile(payload []byte) (*os.File, error) {
+	file, err := os.CreateTemp(s.config.TempDir, "emit-journal-")
+	if err != nil {
+		return nil, err
+	}
+
+	// Unlink immediately - journald reads the data through the descriptor
+	if err := os.Remove(file.Name()); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if _, err := file.Write(payload); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return file, nil
+}
+
+// encodeEntry converts an emit entry into the journald native format
+func (s *JournaldSink) encodeEntry(buf *bytes.Buffer, line []byte) {
+	identifier := s.config.Identifier
+	priority := "6"
+
+	// Plain text and non-JSON lines are sent verbatim as the message
+	if len(line) == 0 || line[0] != '{' || !s.encodeJSONEntry(buf, line, journaldEntryKeys(), &identifier, &priority) {
+		buf.Reset()
+		writeJournaldField(buf, "MESSAGE", string(line))
+	}
+
+	writeJournaldField(buf, "PRIORITY", priority)
+	if identifier != "" {
+		writeJournaldField(buf, "SYSLOG_IDENTIFIER", identifier)
+	}
+}
+
+// journaldKeys are the JSON keys translated into journal fields. Empty keys
+// are not written by the layout.
+type journaldKeys struct {
+	time, level, message, component string
+	file, line, function, caller    string
+	fields                          string
+}
+
+// journaldEntryKeys returns the keys of the default logger's JSON layout
+func journaldEntryKeys() journaldKeys {
+	if defaultLogger == nil {
+		return journaldKeysFor(nil)
+	}
+	return journaldKeysFor(defaultLogger.profile)
+}
+
+// journaldKeysFor returns the keys a layout writes (nil for emit's own)
+func journaldKeysFor(layout *entryLayout) journaldKeys {
+	if layout == nil {
+		layout = logEntryLayout
+	}
+
+	switch layout.shape {
+	case shapeECS:
+		return journaldKeys{time: "@timestamp", level: "log.level", message: "message"}
+	case shapeOTel:
+		return journaldKeys{time: "Timestamp", level: "SeverityText", message: "Body"}
+	case shapeGCP:
+		return journaldKeys{time: "time", level: "severity", message: "message"}
+	}
+
+	keys := journaldKeys{
+		time:      layout.keys.time,
+		level:     layout.keys.level,
+		message:   layout.keys.message,
+		component: layout.keys.component,
+		file:      layout.keys.file,
+		line:      layout.keys.line,
+		function:  layout.keys.function,
+		fields:    layout.keys.fields,
+	}
+	// Layouts without split caller keys write "file:line" under one key
+	if keys.file == "" {
+		keys.caller = layout.keys.caller
+	}
+	return keys
+}
+
+// encodeJSONEntry walks the top-level JSON object in order, writing each
+// member as a journal field. It returns false if the line is not valid JSON.
+func (s *JournaldSink) encodeJSONEntry(buf *bytes.Buffer, line []byte, keys journaldKeys, identifier, priority *string) bool {
+	decoder := json.NewDecoder(bytes.NewReader(line))
+	decoder.UseNumber()
+
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return false
+	}
+
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return false
+		}
+		key, _ := token.(string)
+
+		var value any
+		if err := decoder.Decode(&value); err != nil {
+			return false
+		}
+
+		switch key {
+		case "":
+			// Matches none of the keys a layout leaves unset
+		case keys.message:
+			writeJournaldField(buf, "MESSAGE", journaldValue(value))
+		case keys.level:
+			*priority = journaldPriority(journaldValue(value))
+		case keys.time:
+			// journald records its own receive time
+		case keys.component:
+			if *identifier == "" {
+				*identifier = journaldValue(value)
+			}
+		case keys.file:
+			writeJournaldField(buf, "CODE_FILE", journaldValue(value))
+		case keys.line:
+			writeJournaldField(buf, "CODE_LINE", journaldValue(value))
+		case keys.function:
+			writeJournaldField(buf, "CODE_FUNC", journaldValue(value))
+		case keys.caller:
+			caller := journaldValue(value)
+			if colon := strings.LastIndexByte(caller, ':'); colon >= 0 {
+				writeJournaldField(buf, "CODE_FILE", caller[:colon])
+				writeJournaldField(buf, "CODE_LINE", caller[colon+1:])
+				continue
+			}
+			writeJournaldField(buf, "CODE_FILE", caller)
+		case keys.fields:
+			// Nested user fields from the map-based APIs
+			if nested, ok := value.(map[string]any); ok {
+				for _, nestedKey := range slices.Sorted(maps.Keys(nested)) {
+					writeJournaldUserField(buf, nestedKey, nested[nestedKey])
+				}
+				continue
+			}
+			writeJournaldUserField(buf, key, value)
+		default:
+			writeJournaldUserField(buf, key, value)
+		}
+	}
+
+	return true
+}
+
+// writeJournaldUserField writes a user field under a valid journal field name
+func writeJournaldUserField(buf *bytes.Buffer, key string, value any) {
+	name := journaldFieldName(key)
+	if name == "" {
+		return
+	}
+	if journaldReservedFields[name] {
+		name = "EMIT_" + name
+	}
+	writeJournaldField(buf, name, journaldValue(value))
+}
+
+// writeJournaldField writes NAME=value, switching to the length-prefixed
+// binary form when the value contains a newline
+func writeJournaldField(buf *bytes.Buffer, name, value string) {
+	buf.WriteString(name)
+
+	if strings.IndexByte(value, '\n') < 0 {
+		buf.WriteByte('=')
+		buf.WriteString(value)
+		buf.WriteByte('\n')
+		return
+	}
+
+	var size [8]byte
+	binary.LittleEndian.PutUint64(size[:], uint64(len(value)))
+	buf.WriteByte('\n')
+	buf.Write(size[:])
+	buf.WriteString(value)
+	buf.WriteByte('\n')
+}
+
+// journaldFieldName converts a field key to a valid journal field name:
+// uppercase letters, digits and underscores, not starting with an
+// underscore or digit, at most 64 characters
+func journaldFieldName(key string) string {
+	var name strings.Builder
+	name.Grow(len(key))
+
+	for i := 0; i < len(key) && name.Len() < 64; i++ {
+		c := key[i]
+		switch {
+		case c >= 'a' && c <= 'z':
+			name.WriteByte(c - 'a' + 'A')
+		case c >= 'A' && c <= 'Z':
+			name.WriteByte(c)
+		case c >= '0' && c <= '9':
+			if name.Len() == 0 {
+				name.WriteByte('F')
+			}
+			name.WriteByte(c)
+		case name.Len() > 0:
+			name.WriteByte('_')
+		}
+	}
+
+	return strings.TrimRight(name.String(), "_")
+}
+
+// journaldValue renders a decoded JSON value as journal field data
+func journaldValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		if v {
+			return "true"
+		}
+		return "false"
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return ""
+		}
+		return string(data)
+	}
+}
+
+// journaldPriority maps emit levels, by name or number, to syslog priorities
+func journaldPriority(level string) string {
+	parsed := ParseLogLevel(level)
+	if number, err := strconv.Atoi(level); err == nil {
+		parsed = LogLevel(number)
+	}
+
+	switch parsed {
+	case DEBUG:
+		return "7"
+	case WARN:
+		return "4"
+	case ERROR:
+		return "3"
+	default:
+		return "6"
+	}
+}
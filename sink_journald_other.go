@@ -0,0 +1,36 @@
+//go:build !linux
+
+package emit
+
+import "errors"
+
+// JournaldConfig configures a JournaldSink
+type JournaldConfig struct {
+	SocketPath      string
+	Identifier      string
+	MaxDatagramSize int
+	TempDir         string
+}
+
+// JournaldSink is only available on Linux
+type JournaldSink struct{}
+
+// NewJournaldSink always fails on platforms without journald
+func NewJournaldSink(config JournaldConfig) (*JournaldSink, error) {
+	return nil, errors.New("emit: journald is only supported on linux")
+}
+
+// JournaldAvailable always reports false on platforms without journald
+func JournaldAvailable() bool {
+	return false
+}
+
+// Write is never reached because NewJournaldSink fails
+func (s *JournaldSink) Write(p []byte) (int, error) {
+	return 0, errors.New("emit: journald is only supported on linux")
+}
+
+// Close is a no-op
+func (s *JournaldSink) Close() error {
+	return nil
+}
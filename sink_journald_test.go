@@ -0,0 +1,289 @@
+//go:build linux
+
+package emit
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// listenFakeJournald binds a unixgram socket standing in for journald
+func listenFakeJournald(t *testing.T) (*net.UnixConn, string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "journal.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatalf("Failed to listen on fake journald socket: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return conn, path
+}
+
+// readJournaldEntry receives one datagram, following a passed descriptor if present
+func readJournaldEntry(t *testing.T, conn *net.UnixConn) map[string]string {
+	t.Helper()
+
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+
+	buf := make([]byte, 64*1024)
+	oob := make([]byte, syscall.CmsgSpace(4))
+	n, oobn, _, _, err := conn.ReadMsgUnix(buf, oob)
+	if err != nil {
+		t.Fatalf("Failed to read from fake journald socket: %v", err)
+	}
+	payload := buf[:n]
+
+	if oobn > 0 {
+		messages, err := syscall.ParseSocketControlMessage(oob[:oobn])
+		if err != nil || len(messages) != 1 {
+			t.Fatalf("Failed to parse control message: %v", err)
+		}
+		fds, err := syscall.ParseUnixRights(&messages[0])
+		if err != nil || len(fds) != 1 {
+			t.Fatalf("Failed to parse passed descriptor: %v", err)
+		}
+		file := os.NewFile(uintptr(fds[0]), "journal-entry")
+		defer file.Close()
+
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			t.Fatalf("Failed to seek passed descriptor: %v", err)
+		}
+		if payload, err = io.ReadAll(file); err != nil {
+			t.Fatalf("Failed to read passed descriptor: %v", err)
+		}
+	}
+
+	return parseJournaldPayload(t, payload)
+}
+
+// parseJournaldPayload decodes both NAME=value and binary journal fields
+func parseJournaldPayload(t *testing.T, payload []byte) map[string]string {
+	t.Helper()
+
+	fields := make(map[string]string)
+	for len(payload) > 0 {
+		nl := bytes.IndexByte(payload, '\n')
+		if nl < 0 {
+			t.Fatalf("Unterminated journal field: %q", payload)
+		}
+
+		if eq := bytes.IndexByte(payload[:nl], '='); eq >= 0 {
+			fields[string(payload[:eq])] = string(payload[eq+1 : nl])
+			payload = payload[nl+1:]
+			continue
+		}
+
+		name := string(payload[:nl])
+		size := binary.LittleEndian.Uint64(payload[nl+1 : nl+9])
+		fields[name] = string(payload[nl+9 : nl+9+int(size)])
+		payload = payload[nl+9+int(size)+1:]
+	}
+	return fields
+}
+
+// TestJournaldSinkFields tests translation of emit entries into journal fields
+func TestJournaldSinkFields(t *testing.T) {
+	listener, path := listenFakeJournald(t)
+
+	sink, err := NewJournaldSink(JournaldConfig{SocketPath: path})
+	if err != nil {
+		t.Fatalf("Failed to create journald sink: %v", err)
+	}
+	defer sink.Close()
+
+	testLogger := useTestLogger(t, sink, withComponent("payments", "1.0"), func(l *Logger) { l.piiMode = SHOW_PII })
+
+	testLogger.log(WARN, "Card declined", map[string]any{
+		"order_id": 42,
+		"password": "hunter2",
+		"reason":   "insufficient\nfunds",
+	})
+
+	fields := readJournaldEntry(t, listener)
+
+	expected := map[string]string{
+		"MESSAGE":           "Card declined",
+		"PRIORITY":          "4",
+		"SYSLOG_IDENTIFIER": "payments",
+		"VERSION":           "1.0",
+		"ORDER_ID":          "42",
+		"PASSWORD":          "***MASKED***",
+		"REASON":            "insufficient\nfunds",
+	}
+	for name, value := range expected {
+		if fields[name] != value {
+			t.Errorf("Expected %s=%q, got %q", name, value, fields[name])
+		}
+	}
+
+	// Structured fields are written at the top level
+	testLogger.logStructuredFields(ERROR, "Disk full", ZString("mount", "/var"), ZInt("percent", 100))
+
+	fields = readJournaldEntry(t, listener)
+	if fields["PRIORITY"] != "3" || fields["MOUNT"] != "/var" || fields["PERCENT"] != "100" {
+		t.Errorf("Unexpected structured journal fields: %v", fields)
+	}
+}
+
+// TestJournaldSinkLayoutKeys tests that entries are translated with the keys
+// of the active JSON layout
+func TestJournaldSinkLayoutKeys(t *testing.T) {
+	listener, path := listenFakeJournald(t)
+
+	sink, err := NewJournaldSink(JournaldConfig{SocketPath: path})
+	if err != nil {
+		t.Fatalf("Failed to create journald sink: %v", err)
+	}
+	defer sink.Close()
+
+	tests := []struct {
+		name     string
+		option   testOption
+		expected map[string]string
+		caller   bool // Layout carries the caller in emit's own or a renamed key
+	}{
+		{
+			name: "key map",
+			option: func(l *Logger) {
+				l.profile = &entryLayout{enc: jsonEncoder{}, keys: KeyMap{"message": "msg", "level": "lvl", "component": "service", "file": "src"}.apply(logEntryKeys), shape: shapeLogEntry}
+			},
+			expected: map[string]string{"MESSAGE": "Card declined", "PRIORITY": "4", "SYSLOG_IDENTIFIER": "payments"},
+			caller:   true,
+		},
+		{
+			name:     "encoder config",
+			option:   withEncoderConfig(EncoderConfig{MessageKey: "msg", LevelKey: "severity", CallerKey: "at", LevelEncoding: LEVEL_NUMERIC}),
+			expected: map[string]string{"MESSAGE": "Card declined", "PRIORITY": "4", "SYSLOG_IDENTIFIER": "payments"},
+			caller:   true,
+		},
+		{
+			name:     "ecs",
+			option:   withProfile("ecs"),
+			expected: map[string]string{"MESSAGE": "Card declined", "PRIORITY": "4"},
+		},
+		{
+			name:     "otel",
+			option:   withProfile("otel"),
+			expected: map[string]string{"MESSAGE": "Card declined", "PRIORITY": "4"},
+		},
+		{
+			name:     "gcp",
+			option:   withProfile("gcp"),
+			expected: map[string]string{"MESSAGE": "Card declined", "PRIORITY": "4"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testLogger := useTestLogger(t, sink, withComponent("payments", "1.0"), withCaller(), tt.option)
+			testLogger.log(WARN, "Card declined", map[string]any{"order_id": 42})
+
+			fields := readJournaldEntry(t, listener)
+			for name, value := range tt.expected {
+				if fields[name] != value {
+					t.Errorf("Expected %s=%q, got %q in %v", name, value, fields[name], fields)
+				}
+			}
+			if tt.caller && (filepath.Base(fields["CODE_FILE"]) != "sink_journald_test.go" || fields["CODE_LINE"] == "") {
+				t.Errorf("Expected CODE_FILE and CODE_LINE with the caller, got %v", fields)
+			}
+		})
+	}
+}
+
+// TestJournaldSinkPlainText tests that non-JSON entries are sent as MESSAGE
+func TestJournaldSinkPlainText(t *testing.T) {
+	listener, path := listenFakeJournald(t)
+
+	sink, err := NewJournaldSink(JournaldConfig{SocketPath: path, Identifier: "worker"})
+	if err != nil {
+		t.Fatalf("Failed to create journald sink: %v", err)
+	}
+	defer sink.Close()
+
+	if _, err := sink.Write([]byte("plain text line\n")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	fields := readJournaldEntry(t, listener)
+	if fields["MESSAGE"] != "plain text line" || fields["PRIORITY"] != "6" || fields["SYSLOG_IDENTIFIER"] != "worker" {
+		t.Errorf("Unexpected plain journal fields: %v", fields)
+	}
+}
+
+// TestJournaldSinkLargeEntry tests the descriptor fallback for oversized entries
+func TestJournaldSinkLargeEntry(t *testing.T) {
+	listener, path := listenFakeJournald(t)
+
+	sink, err := NewJournaldSink(JournaldConfig{
+		SocketPath:      path,
+		MaxDatagramSize: 64,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create journald sink: %v", err)
+	}
+	defer sink.Close()
+
+	message := string(bytes.Repeat([]byte("x"), 4096))
+	if _, err := sink.Write([]byte(`{"level":"error","message":"` + message + `"}` + "\n")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	fields := readJournaldEntry(t, listener)
+	if fields["MESSAGE"] != message || fields["PRIORITY"] != "3" {
+		t.Errorf("Large entry was not delivered intact (message length %d)", len(fields["MESSAGE"]))
+	}
+}
+
+// TestSealedMemfd tests that oversized entries are staged in a sealed memory file
+func TestSealedMemfd(t *testing.T) {
+	file, err := sealedMemfd([]byte("MESSAGE=sealed\n"))
+	if errors.Is(err, syscall.ENOSYS) || errors.Is(err, syscall.EPERM) {
+		t.Skipf("memfd_create unavailable: %v", err)
+	}
+	if err != nil {
+		t.Fatalf("sealedMemfd failed: %v", err)
+	}
+	defer file.Close()
+
+	const fGetSeals = 1034
+	seals, _, errno := syscall.Syscall(syscall.SYS_FCNTL, file.Fd(), fGetSeals, 0)
+	if want := uintptr(fSealSeal | fSealShrink | fSealGrow | fSealWrite); errno != 0 || seals != want {
+		t.Errorf("Expected seals %#x, got %#x (%v)", want, seals, errno)
+	}
+	if _, err := file.Write([]byte("more")); err == nil {
+		t.Error("Expected writes to the sealed file to fail")
+	}
+	data, err := io.ReadAll(io.NewSectionReader(file, 0, 1<<20))
+	if err != nil || string(data) != "MESSAGE=sealed\n" {
+		t.Errorf("Expected the payload in the sealed file, got %q (%v)", data, err)
+	}
+}
+
+// TestJournaldFieldName tests journal field name sanitizing
+func TestJournaldFieldName(t *testing.T) {
+	cases := map[string]string{
+		"user_id":    "USER_ID",
+		"http.path":  "HTTP_PATH",
+		"_private":   "PRIVATE",
+		"2fa":        "F2FA",
+		"trailing-":  "TRAILING",
+		"":           "",
+		"MixedCase9": "MIXEDCASE9",
+	}
+	for key, expected := range cases {
+		if got := journaldFieldName(key); got != expected {
+			t.Errorf("journaldFieldName(%q) = %q, expected %q", key, got, expected)
+		}
+	}
+}
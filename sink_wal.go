@@ -0,0 +1,575 @@
+package emit
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// WALConfig configures a WALSink
+type WALConfig struct {
+	// Dir holds the segment files and the delivery checkpoint
+	Dir string
+
+	// SegmentSize is the size at which a new segment file is started (default: 16 MiB)
+	SegmentSize int64
+
+	// MaxDiskUsage caps the total segment size; the oldest undelivered
+	// segments are dropped first when it is exceeded (default: 1 GiB)
+	MaxDiskUsage int64
+
+	// Sync fsyncs every entry before Write returns
+	Sync bool
+
+	// RetryInterval is the wait between delivery attempts while the
+	// wrapped sink is failing (default: 1s)
+	RetryInterval time.Duration
+
+	// OnError is called when the wrapped sink rejects an entry
+	OnError func(error)
+}
+
+// WALStats reports delivery progress for a WALSink
+type WALStats struct {
+	PendingRecords int64 // entries persisted but not yet delivered
+	PendingBytes   int64 // bytes persisted but not yet delivered
+	Segments       int   // segment files on disk
+	DiskBytes      int64 // total size of segment files
+	Delivered      int64 // entries accepted by the wrapped sink
+	Dropped        int64 // entries discarded by the disk usage limit or corruption
+	Failures       int64 // failed delivery attempts
+}
+
+// WALSink persists every entry to a segment file before acknowledging it and
+// delivers entries to the wrapped sink in the background, so nothing is lost
+// while that sink is unavailable or across restarts. Delivery is
+// at-least-once: entries delivered just before a crash may be replayed.
+type WALSink struct {
+	target io.Writer
+	config WALConfig
+
+	mu       sync.Mutex
+	segments []walSegment // oldest first, the last one is being written
+	lastSeq  int64
+	active   *os.File
+	closed   bool
+
+	// Delivery position
+	readSeq   int64
+	readOff   int64
+	readIndex int64
+
+	notify chan struct{}
+	done   chan struct{}
+	wg     sync.WaitGroup
+
+	delivered atomic.Int64
+	dropped   atomic.Int64
+	failures  atomic.Int64
+}
+
+// walSegment tracks the valid contents of one segment file
+type walSegment struct {
+	seq     int64
+	size    int64
+	records int64
+}
+
+// Records are framed as: 4-byte length, 4-byte CRC-32C of the data, data
+const walHeaderSize = 8
+
+const (
+	walSegmentPrefix  = "wal-"
+	walSegmentSuffix  = ".seg"
+	walCheckpointName = "checkpoint"
+)
+
+var walCRCTable = crc32.MakeTable(crc32.Castagnoli)
+
+// Returned by Write after Close
+var errWALClosed = errors.New("emit: wal sink closed")
+
+// NewWALSink wraps target with a disk-backed write-ahead buffer, replaying
+// any entries left undelivered by a previous run
+func NewWALSink(target io.Writer, config WALConfig) (*WALSink, error) {
+	if config.Dir == "" {
+		return nil, errors.New("emit: wal sink requires a directory")
+	}
+	if config.SegmentSize <= 0 {
+		config.SegmentSize = 16 << 20
+	}
+	if config.MaxDiskUsage <= 0 {
+		config.MaxDiskUsage = 1 << 30
+	}
+	if config.RetryInterval <= 0 {
+		config.RetryInterval = time.Second
+	}
+
+	if err := os.MkdirAll(config.Dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	w := &WALSink{
+		target: target,
+		config: config,
+		notify: make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+
+	if err := w.recover(); err != nil {
+		return nil, err
+	}
+	if err := w.rotate(); err != nil {
+		return nil, err
+	}
+
+	w.wg.Add(1)
+	go w.run()
+
+	return w, nil
+}
+
+// Write persists one encoded entry; once it returns the entry survives a
+// restart even if the wrapped sink is down
+func (w *WALSink) Write(p []byte) (int, error) {
+	record := make([]byte, walHeaderSize+len(p))
+	binary.LittleEndian.PutUint32(record[0:4], uint32(len(p)))
+	binary.LittleEndian.PutUint32(record[4:8], crc32.Checksum(p, walCRCTable))
+	copy(record[walHeaderSize:], p)
+
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return 0, errWALClosed
+	}
+
+	current := &w.segments[len(w.segments)-1]
+	if current.size > 0 && current.size+int64(len(record)) > w.config.SegmentSize {
+		if err := w.rotate(); err != nil {
+			w.mu.Unlock()
+			return 0, err
+		}
+		current = &w.segments[len(w.segments)-1]
+	}
+
+	n, err := w.active.Write(record)
+	if err == nil && w.config.Sync {
+		err = w.active.Sync()
+	}
+	if err != nil {
+		// Drop a partially written record so the segment stays readable
+		_ = w.active.Truncate(current.size)
+		w.mu.Unlock()
+		return 0, err
+	}
+
+	current.size += int64(n)
+	current.records++
+	w.enforceDiskLimit()
+	w.mu.Unlock()
+
+	select {
+	case w.notify <- struct{}{}:
+	default:
+	}
+
+	return len(p), nil
+}
+
+// Close stops accepting entries, delivers what it can until the wrapped
+// sink fails or the backlog is empty, and records the delivery position.
+// The wrapped sink is not closed.
+func (w *WALSink) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	w.mu.Unlock()
+
+	close(w.done)
+	w.wg.Wait()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	err := w.saveCheckpoint()
+	if closeErr := w.active.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Stats returns a snapshot of the delivery lag and counters
+func (w *WALSink) Stats() WALStats {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	stats := WALStats{
+		Segments:  len(w.segments),
+		Delivered: w.delivered.Load(),
+		Dropped:   w.dropped.Load(),
+		Failures:  w.failures.Load(),
+	}
+
+	for _, segment := range w.segments {
+		stats.DiskBytes += segment.size
+
+		switch {
+		case segment.seq == w.readSeq:
+			stats.PendingRecords += segment.records - w.readIndex
+			stats.PendingBytes += segment.size - w.readOff
+		case segment.seq > w.readSeq:
+			stats.PendingRecords += segment.records
+			stats.PendingBytes += segment.size
+		}
+	}
+
+	return stats
+}
+
+// segmentPath returns the file name of a segment
+func (w *WALSink) segmentPath(seq int64) string {
+	return filepath.Join(w.config.Dir, fmt.Sprintf("%s%016d%s", walSegmentPrefix, seq, walSegmentSuffix))
+}
+
+// rotate starts a new segment file; callers hold w.mu (or are constructing)
+func (w *WALSink) rotate() error {
+	w.lastSeq++
+	seq := w.lastSeq
+
+	file, err := os.OpenFile(w.segmentPath(seq), os.O_CREATE|os.O_WRONLY|os.O_APPEND|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+
+	if w.active != nil {
+		_ = w.active.Close()
+	}
+	w.active = file
+	w.segments = append(w.segments, walSegment{seq: seq})
+
+	// A fresh WAL starts delivering from the first segment
+	if len(w.segments) == 1 {
+		w.readSeq, w.readOff, w.readIndex = seq, 0, 0
+	}
+
+	return nil
+}
+
+// enforceDiskLimit drops the oldest segments beyond MaxDiskUsage; callers hold w.mu
+func (w *WALSink) enforceDiskLimit() {
+	var total int64
+	for _, segment := range w.segments {
+		total += segment.size
+	}
+
+	for total > w.config.MaxDiskUsage && len(w.segments) > 1 {
+		oldest := w.segments[0]
+
+		lost := oldest.records
+		if oldest.seq == w.readSeq {
+			lost -= w.readIndex
+			w.readSeq, w.readOff, w.readIndex = w.segments[1].seq, 0, 0
+		}
+		w.dropped.Add(lost)
+
+		_ = os.Remove(w.segmentPath(oldest.seq))
+		w.segments = w.segments[1:]
+		total -= oldest.size
+	}
+}
+
+// recover scans existing segments, truncating torn records left by a crash,
+// and restores the delivery position from the checkpoint
+func (w *WALSink) recover() error {
+	entries, err := os.ReadDir(w.config.Dir)
+	if err != nil {
+		return err
+	}
+
+	var seqs []int64
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, walSegmentPrefix) || !strings.HasSuffix(name, walSegmentSuffix) {
+			continue
+		}
+		seq, err := strconv.ParseInt(strings.TrimSuffix(strings.TrimPrefix(name, walSegmentPrefix), walSegmentSuffix), 10, 64)
+		if err == nil {
+			seqs = append(seqs, seq)
+		}
+	}
+	slices.Sort(seqs)
+
+	checkpointSeq, checkpointOff := w.loadCheckpoint()
+
+	// New segments must sort after everything already delivered
+	w.lastSeq = checkpointSeq
+	if len(seqs) > 0 {
+		w.lastSeq = max(w.lastSeq, seqs[len(seqs)-1])
+	}
+
+	for _, seq := range seqs {
+		// Segments before the checkpoint were fully delivered
+		if seq < checkpointSeq {
+			_ = os.Remove(w.segmentPath(seq))
+			continue
+		}
+
+		segment, readIndex, err := w.scanSegment(seq, checkpointSeq, checkpointOff)
+		if err != nil {
+			return err
+		}
+		if len(w.segments) == 0 {
+			w.readSeq, w.readOff, w.readIndex = seq, 0, 0
+			if seq == checkpointSeq {
+				w.readOff, w.readIndex = min(checkpointOff, segment.size), readIndex
+			}
+		}
+		w.segments = append(w.segments, segment)
+	}
+
+	return nil
+}
+
+// scanSegment validates a segment and truncates anything after its last
+// intact record. It also counts the records before the checkpoint offset.
+func (w *WALSink) scanSegment(seq, checkpointSeq, checkpointOff int64) (walSegment, int64, error) {
+	segment := walSegment{seq: seq}
+
+	data, err := os.ReadFile(w.segmentPath(seq))
+	if err != nil {
+		return segment, 0, err
+	}
+
+	var readIndex int64
+	for {
+		record, ok := decodeWALRecord(data[segment.size:])
+		if !ok {
+			break
+		}
+		if seq == checkpointSeq && segment.size < checkpointOff {
+			readIndex++
+		}
+		segment.size += int64(walHeaderSize + len(record))
+		segment.records++
+	}
+
+	if segment.size < int64(len(data)) {
+		if err := os.Truncate(w.segmentPath(seq), segment.size); err != nil {
+			return segment, 0, err
+		}
+	}
+
+	return segment, readIndex, nil
+}
+
+// decodeWALRecord returns the first record in data if it is complete and intact
+func decodeWALRecord(data []byte) ([]byte, bool) {
+	if len(data) < walHeaderSize {
+		return nil, false
+	}
+	size := int(binary.LittleEndian.Uint32(data[0:4]))
+	if len(data) < walHeaderSize+size {
+		return nil, false
+	}
+	record := data[walHeaderSize : walHeaderSize+size]
+	if crc32.Checksum(record, walCRCTable) != binary.LittleEndian.Uint32(data[4:8]) {
+		return nil, false
+	}
+	return record, true
+}
+
+// loadCheckpoint reads the last saved delivery position
+func (w *WALSink) loadCheckpoint() (int64, int64) {
+	data, err := os.ReadFile(filepath.Join(w.config.Dir, walCheckpointName))
+	if err != nil || len(data) != 16 {
+		return 0, 0
+	}
+	return int64(binary.LittleEndian.Uint64(data[0:8])), int64(binary.LittleEndian.Uint64(data[8:16]))
+}
+
+// saveCheckpoint atomically records the delivery position; callers hold w.mu
+func (w *WALSink) saveCheckpoint() error {
+	var data [16]byte
+	binary.LittleEndian.PutUint64(data[0:8], uint64(w.readSeq))
+	binary.LittleEndian.PutUint64(data[8:16], uint64(w.readOff))
+
+	path := filepath.Join(w.config.Dir, walCheckpointName)
+	if err := os.WriteFile(path+".tmp", data[:], 0o644); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// run delivers persisted entries to the wrapped sink in order
+func (w *WALSink) run() {
+	defer w.wg.Done()
+
+	reader := &walReader{}
+	defer reader.close()
+
+	sinceCheckpoint := 0
+	for {
+		seq, offset, record, ok := w.nextRecord(reader)
+		if !ok {
+			// Caught up - persist the position and wait for more entries
+			if sinceCheckpoint > 0 {
+				w.mu.Lock()
+				_ = w.saveCheckpoint()
+				w.mu.Unlock()
+				sinceCheckpoint = 0
+			}
+
+			select {
+			case <-w.notify:
+				continue
+			case <-w.done:
+				return
+			}
+		}
+
+		for {
+			_, err := w.target.Write(record)
+			if err == nil {
+				break
+			}
+
+			w.failures.Add(1)
+			if w.config.OnError != nil {
+				w.config.OnError(err)
+			}
+
+			select {
+			case <-time.After(w.config.RetryInterval):
+			case <-w.done:
+				// Undelivered entries stay on disk for the next run
+				return
+			}
+		}
+
+		w.delivered.Add(1)
+		w.advance(seq, offset+int64(walHeaderSize+len(record)))
+
+		if sinceCheckpoint++; sinceCheckpoint >= 64 {
+			w.mu.Lock()
+			_ = w.saveCheckpoint()
+			w.mu.Unlock()
+			sinceCheckpoint = 0
+		}
+	}
+}
+
+// walReader keeps the segment currently being delivered open
+type walReader struct {
+	seq  int64
+	file *os.File
+}
+
+func (r *walReader) close() {
+	if r.file != nil {
+		_ = r.file.Close()
+		r.file = nil
+	}
+}
+
+// nextRecord returns the next undelivered record, deleting segments as
+// they are fully delivered. ok is false when delivery has caught up.
+func (w *WALSink) nextRecord(reader *walReader) (seq, offset int64, record []byte, ok bool) {
+	for {
+		w.mu.Lock()
+		index := slices.IndexFunc(w.segments, func(s walSegment) bool { return s.seq == w.readSeq })
+		if index < 0 {
+			w.mu.Unlock()
+			return 0, 0, nil, false
+		}
+		segment := w.segments[index]
+		seq, offset = w.readSeq, w.readOff
+
+		if offset >= segment.size {
+			if index == len(w.segments)-1 {
+				// Reading the active segment and nothing new was written
+				w.mu.Unlock()
+				return 0, 0, nil, false
+			}
+
+			// Segment fully delivered - move on and remove it
+			_ = os.Remove(w.segmentPath(segment.seq))
+			w.segments = slices.Delete(w.segments, index, index+1)
+			w.readSeq, w.readOff, w.readIndex = w.segments[index].seq, 0, 0
+			w.mu.Unlock()
+			continue
+		}
+		w.mu.Unlock()
+
+		var data []byte
+		var err error
+		if reader.file == nil || reader.seq != seq {
+			reader.close()
+			reader.seq = seq
+			reader.file, err = os.Open(w.segmentPath(seq))
+		}
+		if err == nil {
+			data, err = readWALRecord(reader.file, offset, segment.size)
+		}
+		if err != nil {
+			// Missing file or corrupt record - the rest of the segment is lost
+			w.mu.Lock()
+			if w.readSeq == seq {
+				w.dropped.Add(segment.records - w.readIndex)
+				w.readOff = segment.size
+				w.readIndex = segment.records
+			}
+			w.mu.Unlock()
+			continue
+		}
+
+		return seq, offset, data, true
+	}
+}
+
+// readWALRecord reads and verifies the record at offset within limit
+func readWALRecord(file *os.File, offset, limit int64) ([]byte, error) {
+	var header [walHeaderSize]byte
+	if _, err := file.ReadAt(header[:], offset); err != nil {
+		return nil, err
+	}
+
+	size := int64(binary.LittleEndian.Uint32(header[0:4]))
+	if offset+walHeaderSize+size > limit {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	record := make([]byte, size)
+	if _, err := file.ReadAt(record, offset+walHeaderSize); err != nil {
+		return nil, err
+	}
+	if crc32.Checksum(record, walCRCTable) != binary.LittleEndian.Uint32(header[4:8]) {
+		return nil, errors.New("emit: wal record checksum mismatch")
+	}
+
+	return record, nil
+}
+
+// advance moves the delivery position past a delivered record, unless the
+// disk limit already moved it elsewhere
+func (w *WALSink) advance(seq, offset int64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.readSeq == seq && w.readOff < offset {
+		w.readOff = offset
+		w.readIndex++
+	}
+}
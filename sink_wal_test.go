@@ -0,0 +1,168 @@
+package emit
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyWriter fails while down is set and records accepted entries
+type flakyWriter struct {
+	mu      sync.Mutex
+	entries []string
+	down    atomic.Bool
+}
+
+func (w *flakyWriter) Write(p []byte) (int, error) {
+	if w.down.Load() {
+		return 0, errors.New("sink unavailable")
+	}
+	w.mu.Lock()
+	w.entries = append(w.entries, string(p))
+	w.mu.Unlock()
+	return len(p), nil
+}
+
+func (w *flakyWriter) received() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]string(nil), w.entries...)
+}
+
+// waitFor polls until condition holds or the deadline passes
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for condition")
+		}
+		time.Sleep(2 * time.Millisecond)
+	}
+}
+
+// TestWALSinkDeliversAfterOutage tests ordered delivery once the wrapped sink recovers
+func TestWALSinkDeliversAfterOutage(t *testing.T) {
+	target := &flakyWriter{}
+	target.down.Store(true)
+
+	wal, err := NewWALSink(target, WALConfig{Dir: t.TempDir(), SegmentSize: 64, RetryInterval: time.Millisecond})
+	if err != nil {
+		t.Fatalf("Failed to create wal sink: %v", err)
+	}
+	defer wal.Close()
+
+	for i := 0; i < 10; i++ {
+		if _, err := wal.Write([]byte(fmt.Sprintf("entry-%d\n", i))); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+
+	waitFor(t, func() bool { return wal.Stats().Failures > 0 })
+	if stats := wal.Stats(); stats.PendingRecords != 10 || stats.Segments < 2 {
+		t.Errorf("Expected 10 pending records across several segments, got %+v", stats)
+	}
+
+	target.down.Store(false)
+	waitFor(t, func() bool { return wal.Stats().PendingRecords == 0 })
+
+	received := target.received()
+	if len(received) != 10 {
+		t.Fatalf("Expected 10 delivered entries, got %d", len(received))
+	}
+	for i, entry := range received {
+		if entry != fmt.Sprintf("entry-%d\n", i) {
+			t.Errorf("Entry %d out of order: %q", i, entry)
+		}
+	}
+
+	// Fully delivered segments are removed
+	if stats := wal.Stats(); stats.Segments != 1 || stats.Delivered != 10 {
+		t.Errorf("Unexpected stats after delivery: %+v", stats)
+	}
+}
+
+// TestWALSinkReplayAfterRestart tests replay of undelivered segments
+func TestWALSinkReplayAfterRestart(t *testing.T) {
+	dir := t.TempDir()
+
+	target := &flakyWriter{}
+	wal, err := NewWALSink(target, WALConfig{Dir: dir, RetryInterval: time.Millisecond})
+	if err != nil {
+		t.Fatalf("Failed to create wal sink: %v", err)
+	}
+	_, _ = wal.Write([]byte("delivered\n"))
+	waitFor(t, func() bool { return wal.Stats().Delivered == 1 })
+
+	target.down.Store(true)
+	_, _ = wal.Write([]byte("pending-1\n"))
+	_, _ = wal.Write([]byte("pending-2\n"))
+	waitFor(t, func() bool { return wal.Stats().Failures > 0 })
+	if err := wal.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	// Simulate a torn record from a crash mid-write
+	segments, _ := filepath.Glob(filepath.Join(dir, "wal-*.seg"))
+	last := segments[len(segments)-1]
+	file, _ := os.OpenFile(last, os.O_APPEND|os.O_WRONLY, 0o644)
+	_, _ = file.Write([]byte{0xff, 0x00, 0x00})
+	file.Close()
+
+	restarted := &flakyWriter{}
+	wal, err = NewWALSink(restarted, WALConfig{Dir: dir, RetryInterval: time.Millisecond})
+	if err != nil {
+		t.Fatalf("Failed to reopen wal sink: %v", err)
+	}
+	defer wal.Close()
+
+	waitFor(t, func() bool { return len(restarted.received()) == 2 })
+	received := restarted.received()
+	if received[0] != "pending-1\n" || received[1] != "pending-2\n" {
+		t.Errorf("Unexpected replayed entries: %q", received)
+	}
+}
+
+// TestWALSinkDiskLimit tests the drop-oldest policy
+func TestWALSinkDiskLimit(t *testing.T) {
+	target := &flakyWriter{}
+	target.down.Store(true)
+
+	wal, err := NewWALSink(target, WALConfig{
+		Dir:           t.TempDir(),
+		SegmentSize:   40,
+		MaxDiskUsage:  100,
+		RetryInterval: time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create wal sink: %v", err)
+	}
+	defer wal.Close()
+
+	// Each record is 8 header bytes + 12 data bytes, two per segment
+	for i := 0; i < 20; i++ {
+		_, _ = wal.Write([]byte(fmt.Sprintf("record-%04d\n", i)))
+	}
+
+	stats := wal.Stats()
+	if stats.DiskBytes > 100 || stats.Dropped == 0 {
+		t.Fatalf("Expected disk usage within limit with drops, got %+v", stats)
+	}
+	if stats.Dropped+stats.PendingRecords != 20 {
+		t.Errorf("Every record should be pending or dropped, got %+v", stats)
+	}
+
+	target.down.Store(false)
+	waitFor(t, func() bool { return wal.Stats().PendingRecords == 0 })
+
+	received := target.received()
+	if received[len(received)-1] != "record-0019\n" {
+		t.Errorf("Newest record should survive, got %q", received)
+	}
+}
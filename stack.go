@@ -0,0 +1,160 @@
+package emit
+
+import (
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// StackTraceConfig configures the stack traces attached to entries
+type StackTraceConfig struct {
+	// Level is the lowest level with a stack trace (such as ERROR)
+	Level LogLevel
+
+	// TrimRuntime leaves out Go runtime and standard library frames
+	TrimRuntime bool
+
+	// MaxFrames bounds the frames captured (default 32)
+	MaxFrames int
+}
+
+// stackOptions holds the stack trace settings of a logger
+type stackOptions struct {
+	level       LogLevel
+	trimRuntime bool
+	maxFrames   int
+}
+
+// stackFrame is one frame of a stack trace
+type stackFrame struct {
+	function string
+	file     string
+	line     int
+}
+
+// stackBuffer holds the program counters and frames of one capture
+type stackBuffer struct {
+	pcs    []uintptr
+	frames []stackFrame
+}
+
+// Program counters captured beyond MaxFrames and the caller skip, for
+// emit's own frames and the runtime frames TrimRuntime leaves out
+const stackFrameSlack = 32
+
+// stackPool reuses capture buffers across entries
+var stackPool = sync.Pool{
+	New: func() any {
+		return &stackBuffer{pcs: make([]uintptr, 64)}
+	},
+}
+
+// SetStackTrace attaches a stack trace to the default logger's entries at
+// or above the configured level: a "stack" array of {function, file, line}
+// frames in JSON, and indented lines below the entry in text formats
+func SetStackTrace(config StackTraceConfig) {
+	if config.MaxFrames <= 0 {
+		config.MaxFrames = 32
+	}
+	if defaultLogger != nil {
+		defaultLogger.stacks = &stackOptions{level: config.Level, trimRuntime: config.TrimRuntime, maxFrames: config.MaxFrames}
+	}
+}
+
+// DisableStackTrace stops attaching stack traces to the default logger's entries
+func DisableStackTrace() {
+	if defaultLogger != nil {
+		defaultLogger.stacks = nil
+	}
+}
+
+// wantsStack reports whether entries at level get a stack trace
+func (l *Logger) wantsStack(level LogLevel) bool {
+	return l.stacks != nil && level >= l.stacks.level
+}
+
+// captureStack captures the stack of the logging call site, leaving out
+// emit's own frames, the caller skip and optionally runtime frames. The
+// buffer goes back to stackPool once the frames are written.
+func (l *Logger) captureStack() *stackBuffer {
+	s := stackPool.Get().(*stackBuffer)
+	s.frames = s.frames[:0]
+	if need := l.stacks.maxFrames + l.callerSkip + stackFrameSlack; len(s.pcs) < need {
+		s.pcs = make([]uintptr, need)
+	}
+
+	frames := runtime.CallersFrames(s.pcs[:runtime.Callers(2, s.pcs)])
+	seenEmit := false
+	skip := l.callerSkip
+	for len(s.frames) < l.stacks.maxFrames {
+		frame, more := frames.Next()
+		switch {
+		case isEmitFrame(frame), seenEmit && len(s.frames) == 0 && isStdLogFrame(frame):
+			seenEmit = true
+		case !seenEmit:
+			// Frames called by emit, such as hooks
+		case skip > 0:
+			skip--
+		case l.stacks.trimRuntime && isStandardFrame(frame.Function):
+		default:
+			s.frames = append(s.frames, stackFrame{function: frame.Function, file: l.callerFile(frame.File), line: frame.Line})
+		}
+		if !more {
+			break
+		}
+	}
+	return s
+}
+
+// isStandardFrame reports whether a function belongs to the Go runtime or
+// standard library, whose import paths have no dot in the first element
+func isStandardFrame(function string) bool {
+	first := function
+	if slash := strings.IndexByte(first, '/'); slash >= 0 {
+		first = first[:slash]
+	} else if dot := strings.IndexByte(first, '.'); dot >= 0 {
+		first = first[:dot]
+	}
+	return first != "main" && !strings.Contains(first, ".")
+}
+
+// appendStack appends the entry's stack trace if its level wants one
+func (l *Logger) appendStack(enc entryEncoder, buf []byte, level LogLevel) []byte {
+	if !l.wantsStack(level) {
+		return buf
+	}
+	s := l.captureStack()
+	buf = enc.appendStack(buf, "stack", s.frames)
+	stackPool.Put(s)
+	return buf
+}
+
+// appendStackText appends a stack trace as indented lines below an entry
+func (l *Logger) appendStackText(buf []byte, level LogLevel) []byte {
+	if !l.wantsStack(level) {
+		return buf
+	}
+	s := l.captureStack()
+	buf = append(buf, "  stack:\n"...)
+	buf = appendStackLines(buf, s.frames, "    ")
+	stackPool.Put(s)
+	return buf
+}
+
+// appendStackLines writes each frame as its function then its indented
+// file:line, like a Go panic
+func appendStackLines(buf []byte, frames []stackFrame, indent string) []byte {
+	for _, frame := range frames {
+		buf = append(buf, indent...)
+		buf = append(buf, frame.function...)
+		buf = append(buf, '\n')
+		buf = append(buf, indent...)
+		buf = append(buf, "    "...)
+		buf = append(buf, frame.file...)
+		buf = append(buf, ':')
+		buf = strconv.AppendInt(buf, int64(frame.line), 10)
+		buf = append(buf, '\n')
+	}
+	return buf
+}
@@ -0,0 +1,138 @@
+package emit
+
+import (
+	"bytes"
+	"encoding/json"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// TestStackTraceJSON tests the frames array on every JSON path
+func TestStackTraceJSON(t *testing.T) {
+	var buf bytes.Buffer
+	testLogger := newTestLogger(&buf, withFormat(JSON_FORMAT), withStackTraces(false))
+
+	testLogger.log(ERROR, "simple", nil)
+	testLogger.log(ERROR, "map", map[string]any{"k": "v"})
+	testLogger.logStructuredFields(ERROR, "structured", ZString("k", "v"))
+	testLogger.profile = otelLayout
+	testLogger.log(ERROR, "profile", nil)
+	testLogger.profile = nil
+	testLogger.log(WARN, "below threshold", nil)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("Expected 5 entries, got %q", buf.String())
+	}
+	for _, line := range lines[:4] {
+		var entry struct {
+			Stack []struct {
+				Function string `json:"function"`
+				File     string `json:"file"`
+				Line     int    `json:"line"`
+			} `json:"stack"`
+		}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("Invalid JSON %s: %v", line, err)
+		}
+		if len(entry.Stack) == 0 {
+			t.Fatalf("Expected a stack trace in %s", line)
+		}
+		top := entry.Stack[0]
+		if top.Function != "github.com/cloudresty/emit.TestStackTraceJSON" || !strings.HasSuffix(top.File, "/stack_test.go") || top.Line == 0 {
+			t.Errorf("Expected the test function on top, got %+v", top)
+		}
+		for _, frame := range entry.Stack {
+			if isEmitFrame(runtime.Frame{Function: frame.Function, File: frame.File}) {
+				t.Errorf("Unexpected emit frame %+v", frame)
+			}
+		}
+	}
+	if strings.Contains(lines[4], "stack") {
+		t.Errorf("Expected no stack trace below the threshold: %s", lines[4])
+	}
+}
+
+// TestStackTraceMaxFrames tests that stacks deeper than the pooled buffer
+// keep MaxFrames frames
+func TestStackTraceMaxFrames(t *testing.T) {
+	var buf bytes.Buffer
+	testLogger := newTestLogger(&buf, withStackTraces(false))
+	testLogger.stacks.maxFrames = 100
+
+	var recurse func(depth int)
+	recurse = func(depth int) {
+		if depth == 0 {
+			testLogger.log(ERROR, "deep", nil)
+			return
+		}
+		recurse(depth - 1)
+	}
+	recurse(150)
+
+	var entry struct {
+		Stack []json.RawMessage `json:"stack"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Invalid JSON %s: %v", buf.String(), err)
+	}
+	if len(entry.Stack) != 100 {
+		t.Errorf("Expected 100 frames, got %d", len(entry.Stack))
+	}
+}
+
+// TestStackTraceText tests indented frames below plain and console entries
+func TestStackTraceText(t *testing.T) {
+	for _, format := range []OutputFormat{PLAIN_FORMAT, CONSOLE_FORMAT} {
+		var buf bytes.Buffer
+		testLogger := newTestLogger(&buf, withFormat(format), withStackTraces(true))
+		testLogger.log(ERROR, "failed", map[string]any{"k": "v"})
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		if len(lines) != 4 {
+			t.Fatalf("Format %d: expected the entry and one frame, got %q", format, buf.String())
+		}
+		if lines[1] != "  stack:" || lines[2] != "    github.com/cloudresty/emit.TestStackTraceText" || !strings.HasPrefix(lines[3], "        /") || !strings.Contains(lines[3], "/stack_test.go:") {
+			t.Errorf("Format %d: unexpected stack lines %q", format, lines[1:])
+		}
+	}
+}
+
+// TestStackTraceLogfmt tests the quoted stack of single line formats
+func TestStackTraceLogfmt(t *testing.T) {
+	var buf bytes.Buffer
+	testLogger := newTestLogger(&buf, withFormat(LOGFMT_FORMAT), withStackTraces(true))
+	testLogger.logStructuredFields(ERROR, "failed")
+
+	if !strings.Contains(buf.String(), `stack="github.com/cloudresty/emit.TestStackTraceLogfmt\n    /`) || strings.Count(buf.String(), "\n") != 1 {
+		t.Errorf("Unexpected logfmt stack %q", buf.String())
+	}
+}
+
+// TestIsStandardFrame tests telling runtime and standard library frames apart
+func TestIsStandardFrame(t *testing.T) {
+	for function, want := range map[string]bool{
+		"runtime.goexit":                     true,
+		"testing.tRunner":                    true,
+		"net/http.(*conn).serve":             true,
+		"main.main":                          false,
+		"github.com/cloudresty/emit.Test":    false,
+		"gopkg.in/yaml%2ev3.(*decoder).node": false,
+	} {
+		if got := isStandardFrame(function); got != want {
+			t.Errorf("%s: expected %v, got %v", function, want, got)
+		}
+	}
+}
+
+// BenchmarkStackTrace measures capturing a stack trace for an error entry
+func BenchmarkStackTrace(b *testing.B) {
+	var buf bytes.Buffer
+	testLogger := newTestLogger(&buf, withFormat(JSON_FORMAT), withStackTraces(true))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf.Reset()
+		testLogger.logStructuredFields(ERROR, "failed", ZInt("attempt", i))
+	}
+}
@@ -0,0 +1,159 @@
+package emit
+
+import (
+	"log"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// StdLogOption adjusts how standard library log lines become entries
+type StdLogOption int
+
+const (
+	// STDLOG_KEY_VALUES parses trailing key=value pairs (values optionally
+	// quoted) into fields, which are then masked like any other field
+	STDLOG_KEY_VALUES StdLogOption = iota + 1
+)
+
+// stdLogWriter turns the lines of a standard library logger into entries
+// of the default logger
+type stdLogWriter struct {
+	level     LogLevel
+	keyValues bool
+}
+
+// newStdLogWriter returns a writer logging at level with the options applied
+func newStdLogWriter(level LogLevel, options []StdLogOption) *stdLogWriter {
+	return &stdLogWriter{level: level, keyValues: slices.Contains(options, STDLOG_KEY_VALUES)}
+}
+
+// Write logs one line written by the standard library logger
+func (w *stdLogWriter) Write(p []byte) (int, error) {
+	if defaultLogger == nil {
+		return len(p), nil
+	}
+
+	message := strings.TrimSuffix(string(p), "\n")
+	var fields map[string]any
+	if w.keyValues {
+		message, fields = splitKeyValues(message)
+	}
+	defaultLogger.log(w.level, message, fields)
+	return len(p), nil
+}
+
+// NewStdLogger returns a standard library logger writing its lines to the
+// default logger at level, for packages that accept a *log.Logger
+func NewStdLogger(level LogLevel, options ...StdLogOption) *log.Logger {
+	return log.New(newStdLogWriter(level, options), "", 0)
+}
+
+// RedirectStdLog routes the standard library's default logger (log.Printf
+// and friends) to the default logger at level. Its prefix and flags are
+// cleared, since emit writes its own timestamp and caller. The returned
+// function restores the original output, prefix and flags.
+func RedirectStdLog(level LogLevel, options ...StdLogOption) func() {
+	output, prefix, flags := log.Writer(), log.Prefix(), log.Flags()
+
+	log.SetOutput(newStdLogWriter(level, options))
+	log.SetPrefix("")
+	log.SetFlags(0)
+
+	return func() {
+		log.SetOutput(output)
+		log.SetPrefix(prefix)
+		log.SetFlags(flags)
+	}
+}
+
+// isStdLogFrame reports whether a frame belongs to the standard library
+// log package, which sits between emit and the caller of a bridged logger
+func isStdLogFrame(frame runtime.Frame) bool {
+	return strings.HasPrefix(frame.Function, "log.")
+}
+
+// splitKeyValues splits the trailing key=value pairs off a line, returning
+// the rest as the message
+func splitKeyValues(line string) (string, map[string]any) {
+	type pair struct{ key, value string }
+	var pairs []pair
+	end := len(line)
+
+	for i := 0; i < len(line); {
+		if line[i] == ' ' {
+			i++
+			continue
+		}
+		key, value, next, ok := scanKeyValue(line, i)
+		if !ok {
+			// Only the pairs after the last plain word are fields
+			pairs = pairs[:0]
+			end = len(line)
+		} else {
+			if len(pairs) == 0 {
+				end = i
+			}
+			pairs = append(pairs, pair{key, value})
+		}
+		i = next
+	}
+
+	if len(pairs) == 0 {
+		return line, nil
+	}
+	fields := make(map[string]any, len(pairs))
+	for _, p := range pairs {
+		fields[p.key] = p.value
+	}
+	return strings.TrimRight(line[:end], " "), fields
+}
+
+// scanKeyValue reads the word starting at i as a key=value pair, returning
+// the index after the word either way
+func scanKeyValue(line string, i int) (key, value string, next int, ok bool) {
+	start := i
+	for i < len(line) && isKeyByte(line[i]) {
+		i++
+	}
+	if i == start || i == len(line) || line[i] != '=' {
+		return "", "", skipWord(line, i), false
+	}
+	key = line[start:i]
+	i++
+
+	if i < len(line) && line[i] == '"' {
+		quoteEnd := i + 1
+		for quoteEnd < len(line) && line[quoteEnd] != '"' {
+			if line[quoteEnd] == '\\' {
+				quoteEnd++
+			}
+			quoteEnd++
+		}
+		if quoteEnd >= len(line) {
+			return "", "", len(line), false
+		}
+		unquoted, err := strconv.Unquote(line[i : quoteEnd+1])
+		if err != nil || quoteEnd+1 < len(line) && line[quoteEnd+1] != ' ' {
+			return "", "", skipWord(line, quoteEnd+1), false
+		}
+		return key, unquoted, quoteEnd + 1, true
+	}
+
+	next = skipWord(line, i)
+	return key, line[i:next], next, true
+}
+
+// isKeyByte reports whether c can appear in a field key
+func isKeyByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-' || c == '.'
+}
+
+// skipWord returns the index of the space ending the word at i
+func skipWord(line string, i int) int {
+	for i < len(line) && line[i] != ' ' {
+		i++
+	}
+	return i
+}
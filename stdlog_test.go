@@ -0,0 +1,114 @@
+package emit
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestNewStdLogger tests that standard library lines become entries at the chosen level
+func TestNewStdLogger(t *testing.T) {
+	var buf bytes.Buffer
+	testLogger := useTestLogger(t, &buf, withFormat(JSON_FORMAT), withComponent("api", ""), withCaller())
+	testLogger.showCaller = false
+
+	stdLogger := NewStdLogger(WARN)
+	stdLogger.Printf("disk %d%% full", 91)
+
+	var entry struct {
+		Level   string         `json:"level"`
+		Message string         `json:"message"`
+		Fields  map[string]any `json:"fields"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Invalid JSON %q: %v", buf.String(), err)
+	}
+	if entry.Level != "warn" || entry.Message != "disk 91% full" || entry.Fields != nil {
+		t.Errorf("Expected a warn entry without fields, got %s", buf.String())
+	}
+}
+
+// TestRedirectStdLog tests redirecting log.Printf and restoring it
+func TestRedirectStdLog(t *testing.T) {
+	output, prefix, flags := log.Writer(), log.Prefix(), log.Flags()
+	var original bytes.Buffer
+	log.SetOutput(&original)
+	log.SetPrefix("app: ")
+	log.SetFlags(log.LstdFlags)
+	defer func() {
+		log.SetOutput(output)
+		log.SetPrefix(prefix)
+		log.SetFlags(flags)
+	}()
+
+	var buf bytes.Buffer
+	useTestLogger(t, &buf, withFormat(LOGFMT_FORMAT), withComponent("api", ""), withCaller())
+
+	undo := RedirectStdLog(INFO)
+	line := thisLine() + 1
+	log.Printf("connected to %s", "db")
+	undo()
+	log.Print("after undo")
+
+	if !strings.Contains(buf.String(), `msg="connected to db"`) || strings.Contains(buf.String(), "app:") {
+		t.Errorf("Expected the line without prefix or flags, got %q", buf.String())
+	}
+	if !strings.Contains(buf.String(), "stdlog_test.go:"+strconv.Itoa(line)) {
+		t.Errorf("Expected the log.Printf call site as caller, got %q", buf.String())
+	}
+	if !strings.HasPrefix(original.String(), "app: ") || !strings.HasSuffix(original.String(), "after undo\n") {
+		t.Errorf("Expected the original output, prefix and flags restored, got %q", original.String())
+	}
+	if log.Flags() != log.LstdFlags || log.Prefix() != "app: " {
+		t.Errorf("Expected flags and prefix restored, got %d %q", log.Flags(), log.Prefix())
+	}
+}
+
+// TestStdLogKeyValues tests parsing and masking trailing key=value pairs
+func TestStdLogKeyValues(t *testing.T) {
+	var buf bytes.Buffer
+	testLogger := useTestLogger(t, &buf, withFormat(JSON_FORMAT), withComponent("api", ""), withCaller())
+	testLogger.showCaller = false
+
+	NewStdLogger(INFO, STDLOG_KEY_VALUES).Print(`login failed user=alice password=hunter2 reason="bad password"`)
+
+	var entry struct {
+		Message string         `json:"message"`
+		Fields  map[string]any `json:"fields"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Invalid JSON %q: %v", buf.String(), err)
+	}
+	want := map[string]any{"user": "alice", "password": "***MASKED***", "reason": "bad password"}
+	if entry.Message != "login failed" || !reflect.DeepEqual(entry.Fields, want) {
+		t.Errorf("Expected %q with %v, got %s", "login failed", want, buf.String())
+	}
+}
+
+// TestSplitKeyValues tests which words become fields
+func TestSplitKeyValues(t *testing.T) {
+	tests := []struct {
+		line    string
+		message string
+		fields  map[string]any
+	}{
+		{"plain message", "plain message", nil},
+		{"a=1 in the middle", "a=1 in the middle", nil},
+		{"retry attempt=3 delay=1s", "retry", map[string]any{"attempt": "3", "delay": "1s"}},
+		{`quoted msg="a \"b\" c"`, "quoted", map[string]any{"msg": `a "b" c`}},
+		{`unterminated k="v`, `unterminated k="v`, nil},
+		{"=value k=v", "=value", map[string]any{"k": "v"}},
+		{"k=", "", map[string]any{"k": ""}},
+	}
+
+	for _, tt := range tests {
+		message, fields := splitKeyValues(tt.line)
+		if message != tt.message || !reflect.DeepEqual(fields, tt.fields) {
+			t.Errorf("splitKeyValues(%q) = %q, %v; want %q, %v", tt.line, message, fields, tt.message, tt.fields)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package emit
 
 import (
+	"strconv"
 	"sync/atomic"
 	"time"
 )
@@ -28,152 +29,173 @@ func GetTimestampPrecision() TimestampPrecision {
 	return TimestampPrecision(atomic.LoadInt32(&currentTimestampPrecision))
 }
 
-// ultraFastTimestampCache provides extremely fast timestamp generation
-// by caching timestamp strings and updating them less frequently
-type ultraFastTimestampCache struct {
-	// Atomic fields - must be 64-bit aligned on 32-bit systems
-	lastUpdateUnix int64
+// TimeFormat selects how a TimeEncoder writes entry timestamps
+type TimeFormat int
 
-	// Cached timestamp string - using atomic.Value for safe concurrent access
-	cachedTimestamp atomic.Value // stores string
+const (
+	TIME_RFC3339       TimeFormat = iota // RFC 3339 with the TimestampPrecision fraction (default)
+	TIME_EPOCH_SECONDS                   // Seconds since the epoch as a number, with the precision's fraction
+	TIME_EPOCH_MILLIS                    // Milliseconds since the epoch as a number
+	TIME_EPOCH_NANOS                     // Nanoseconds since the epoch as a number
+	TIME_LAYOUT                          // A custom Go time layout
+)
 
-	// Update frequency (in seconds) - how often to refresh timestamp
-	updateIntervalSeconds int64
-}
+// TimeEncoder configures the entry timestamps written by every formatter
+type TimeEncoder struct {
+	Format TimeFormat
 
-var (
-	// Global ultra-fast cache instance - optimized for sub-20ns performance
-	globalUltraFastCache = &ultraFastTimestampCache{
-		updateIntervalSeconds: 1, // Update every 1 second (but cache at nanosecond level)
-	}
+	// Layout is the Go time layout of TIME_LAYOUT
+	Layout string
 
-	// Thread-safe timestamp check tracker
-	lastTimestampCheck int64 // Use atomic operations for this
-)
+	// Location is the zone of RFC 3339 and layout timestamps: nil for UTC,
+	// time.Local for the local zone or a zone from time.FixedZone
+	Location *time.Location
+}
 
-// GetUltraFastTimestamp returns a cached timestamp string
-// Optimized for sub-20ns performance in the common case
-func GetUltraFastTimestamp() string {
-	// Ultra-fast path: Check if we even need to update (minimize atomic ops)
-	now := time.Now().Unix()
-
-	// Only check atomic lastUpdate occasionally to reduce overhead
-	// Use atomic operations for thread safety
-	lastCheck := atomic.LoadInt64(&lastTimestampCheck)
-	if now == lastCheck {
-		// Same second as last check - return cached string directly
-		if cached := globalUltraFastCache.cachedTimestamp.Load(); cached != nil {
-			return cached.(string)
-		}
-	}
+// timeEncoder is a TimeEncoder ready for use, caching the formatted
+// prefix of the current second for RFC 3339 timestamps
+type timeEncoder struct {
+	format   TimeFormat
+	layout   string
+	location *time.Location
+	maxLen   int  // Longest timestamp written
+	escape   bool // Layout output needs JSON escaping
+	quote    bool // Layout output needs logfmt quoting
+
+	prefix atomic.Pointer[secondPrefix]
+}
+
+// secondPrefix is the "2006-01-02T15:04:05" prefix and zone suffix of one second
+type secondPrefix struct {
+	unix int64
+	text [19]byte
+	zone string
+}
 
-	// Update our local check atomically
-	atomic.StoreInt64(&lastTimestampCheck, now)
+// Longest RFC 3339 or epoch timestamp (nanoseconds with a zone offset)
+const maxTimestampLen = len("2006-01-02T15:04:05.000000000+00:00")
 
-	// Check if we need a real update
-	lastUpdate := atomic.LoadInt64(&globalUltraFastCache.lastUpdateUnix)
+// defaultTimeEncoder writes RFC 3339 UTC timestamps
+var defaultTimeEncoder = newTimeEncoder(TimeEncoder{})
 
-	if now-lastUpdate < atomic.LoadInt64(&globalUltraFastCache.updateIntervalSeconds) {
-		// Return cached timestamp
-		if cached := globalUltraFastCache.cachedTimestamp.Load(); cached != nil {
-			return cached.(string)
-		}
+// newTimeEncoder prepares a TimeEncoder
+func newTimeEncoder(config TimeEncoder) *timeEncoder {
+	if config.Location == nil {
+		config.Location = time.UTC
 	}
-
-	// Time to update - try to win the race
-	if atomic.CompareAndSwapInt64(&globalUltraFastCache.lastUpdateUnix, lastUpdate, now) {
-		// We won the race - generate new timestamp
-		newTimestamp := generateFastTimestamp()
-		globalUltraFastCache.cachedTimestamp.Store(newTimestamp)
-		return newTimestamp
+	if config.Format == TIME_LAYOUT && config.Layout == "" {
+		config.Format = TIME_RFC3339
 	}
-
-	// Another goroutine updated it, return the cached version
-	if cached := globalUltraFastCache.cachedTimestamp.Load(); cached != nil {
-		return cached.(string)
+	e := &timeEncoder{format: config.Format, layout: config.Layout, location: config.Location, maxLen: maxTimestampLen}
+	if e.format == TIME_LAYOUT {
+		// Layout elements at most double in length (such as "1" for
+		// "12"), with room for long zone abbreviations
+		e.maxLen = 2*len(e.layout) + 16
+		sample := time.Date(2006, time.September, 28, 22, 44, 55, 0, e.location).Format(e.layout)
+		e.escape = len(appendJSONString(nil, sample)) != len(sample)+2
+		e.quote = logfmtNeedsQuotes(sample)
 	}
-
-	// First time initialization (rarely called)
-	timestamp := generateFastTimestamp()
-	globalUltraFastCache.cachedTimestamp.Store(timestamp)
-	atomic.StoreInt64(&globalUltraFastCache.lastUpdateUnix, now)
-	return timestamp
+	return e
 }
 
-// generateFastTimestamp creates a timestamp string with millisecond precision
-// This is only called once per second to update the cache
-func generateFastTimestamp() string {
-	now := time.Now().UTC()
-
-	// Pre-calculate the most common case: millisecond precision
-	// Format: 2006-01-02T15:04:05.000Z
-
-	year := now.Year()
-	month := int(now.Month())
-	day := now.Day()
-	hour := now.Hour()
-	minute := now.Minute()
-	second := now.Second()
-	millis := now.Nanosecond() / 1000000
-
-	// Build timestamp string efficiently
-	// Using a fixed-size byte array for better performance
-	var buf [24]byte
-
-	// Year (4 digits)
-	buf[0] = byte('0' + year/1000)
-	buf[1] = byte('0' + (year%1000)/100)
-	buf[2] = byte('0' + (year%100)/10)
-	buf[3] = byte('0' + year%10)
-
-	buf[4] = '-'
-
-	// Month (2 digits)
-	buf[5] = byte('0' + month/10)
-	buf[6] = byte('0' + month%10)
-
-	buf[7] = '-'
-
-	// Day (2 digits)
-	buf[8] = byte('0' + day/10)
-	buf[9] = byte('0' + day%10)
-
-	buf[10] = 'T'
-
-	// Hour (2 digits)
-	buf[11] = byte('0' + hour/10)
-	buf[12] = byte('0' + hour%10)
-
-	buf[13] = ':'
+// SetTimeEncoder sets how the default logger writes entry timestamps
+func SetTimeEncoder(encoder TimeEncoder) {
+	if defaultLogger != nil {
+		defaultLogger.timeEncoder = newTimeEncoder(encoder)
+	}
+}
 
-	// Minute (2 digits)
-	buf[14] = byte('0' + minute/10)
-	buf[15] = byte('0' + minute%10)
+// times returns the logger's time encoder
+func (l *Logger) times() *timeEncoder {
+	if l.timeEncoder != nil {
+		return l.timeEncoder
+	}
+	return defaultTimeEncoder
+}
 
-	buf[16] = ':'
+// GetUltraFastTimestamp returns the default logger clock's current UTC
+// timestamp at the configured precision. Formatters append timestamps with their logger's time encoder
+// instead, which does not allocate.
+func GetUltraFastTimestamp() string {
+	now := time.Now()
+	if defaultLogger != nil {
+		now = defaultLogger.now()
+	}
+	var scratch [maxTimestampLen]byte
+	return string(defaultTimeEncoder.append(scratch[:0], now))
+}
 
-	// Second (2 digits)
-	buf[17] = byte('0' + second/10)
-	buf[18] = byte('0' + second%10)
+// numeric reports whether timestamps are numbers rather than strings
+func (e *timeEncoder) numeric() bool {
+	switch e.format {
+	case TIME_EPOCH_SECONDS, TIME_EPOCH_MILLIS, TIME_EPOCH_NANOS:
+		return true
+	default:
+		return false
+	}
+}
 
-	buf[19] = '.'
+// append appends now in the encoder's format. RFC 3339 timestamps reuse
+// the cached prefix of the current second and only format the fraction.
+func (e *timeEncoder) append(dst []byte, now time.Time) []byte {
+	switch e.format {
+	case TIME_EPOCH_SECONDS:
+		dst = strconv.AppendInt(dst, now.Unix(), 10)
+		return appendPrecisionFraction(dst, now)
+	case TIME_EPOCH_MILLIS:
+		return strconv.AppendInt(dst, now.UnixMilli(), 10)
+	case TIME_EPOCH_NANOS:
+		return strconv.AppendInt(dst, now.UnixNano(), 10)
+	case TIME_LAYOUT:
+		return now.In(e.location).AppendFormat(dst, e.layout)
+	}
 
-	// Milliseconds (3 digits)
-	buf[20] = byte('0' + millis/100)
-	buf[21] = byte('0' + (millis%100)/10)
-	buf[22] = byte('0' + millis%10)
+	now = now.In(e.location)
+	prefix := e.prefix.Load()
+	if unix := now.Unix(); prefix == nil || prefix.unix != unix {
+		prefix = newSecondPrefix(now)
+		e.prefix.Store(prefix)
+	}
+	dst = append(dst, prefix.text[:]...)
+	dst = appendPrecisionFraction(dst, now)
+	return append(dst, prefix.zone...)
+}
 
-	buf[23] = 'Z'
+// newSecondPrefix formats the prefix of the second containing t, in t's zone
+func newSecondPrefix(t time.Time) *secondPrefix {
+	prefix := &secondPrefix{unix: t.Unix(), zone: t.Format("Z07:00")}
+	t.AppendFormat(prefix.text[:0], "2006-01-02T15:04:05")
+	return prefix
+}
 
-	// Convert to string without allocation
-	return string(buf[:])
+// appendPrecisionFraction appends the fractional seconds of the configured precision
+func appendPrecisionFraction(dst []byte, now time.Time) []byte {
+	switch GetTimestampPrecision() {
+	case SecondPrecision:
+		return dst
+	case MillisecondPrecision:
+		return appendFraction(dst, now.Nanosecond()/int(time.Millisecond), 3)
+	case MicrosecondPrecision:
+		return appendFraction(dst, now.Nanosecond()/int(time.Microsecond), 6)
+	default:
+		return appendFraction(dst, now.Nanosecond(), 9)
+	}
 }
 
-// SetUltraFastTimestampPrecision sets the update interval for the ultra-fast cache
-// Lower intervals provide more accurate timestamps but slight performance cost
-func SetUltraFastTimestampPrecision(intervalSeconds int64) {
-	if intervalSeconds < 1 {
-		intervalSeconds = 1
+// appendFraction appends a '.' and value zero-padded to digits
+func appendFraction(dst []byte, value, digits int) []byte {
+	var scratch [9]byte
+	for i := digits - 1; i >= 0; i-- {
+		scratch[i] = byte('0' + value%10)
+		value /= 10
 	}
-	atomic.StoreInt64(&globalUltraFastCache.updateIntervalSeconds, intervalSeconds)
+	dst = append(dst, '.')
+	return append(dst, scratch[:digits]...)
 }
+
+// SetUltraFastTimestampPrecision used to set how often the cached timestamp
+// was refreshed.
+//
+// Deprecated: timestamps are exact at the configured TimestampPrecision;
+// the interval is ignored.
+func SetUltraFastTimestampPrecision(intervalSeconds int64) {}
@@ -0,0 +1,222 @@
+package emit
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudresty/emit/emittest"
+)
+
+// setTestPrecision sets the timestamp precision for one test
+func setTestPrecision(t *testing.T, precision TimestampPrecision) {
+	previous := GetTimestampPrecision()
+	SetTimestampPrecision(precision)
+	t.Cleanup(func() { SetTimestampPrecision(previous) })
+}
+
+// TestAppendTimestampPrecision tests the fraction written at each precision
+func TestAppendTimestampPrecision(t *testing.T) {
+	at := time.Date(2024, 5, 1, 12, 30, 45, 123456789, time.FixedZone("CEST", 2*60*60))
+
+	tests := []struct {
+		precision TimestampPrecision
+		want      string
+	}{
+		{NanosecondPrecision, "2024-05-01T10:30:45.123456789Z"},
+		{MicrosecondPrecision, "2024-05-01T10:30:45.123456Z"},
+		{MillisecondPrecision, "2024-05-01T10:30:45.123Z"},
+		{SecondPrecision, "2024-05-01T10:30:45Z"},
+	}
+
+	for _, tt := range tests {
+		setTestPrecision(t, tt.precision)
+		if got := string(defaultTimeEncoder.append(nil, at)); got != tt.want {
+			t.Errorf("Precision %d: expected %s, got %s", tt.precision, tt.want, got)
+		}
+	}
+}
+
+// TestAppendTimestampSecondChange tests that the cached prefix follows the clock
+func TestAppendTimestampSecondChange(t *testing.T) {
+	setTestPrecision(t, MillisecondPrecision)
+
+	at := time.Date(2024, 12, 31, 23, 59, 59, 999000000, time.UTC)
+	first := string(defaultTimeEncoder.append(nil, at))
+	second := string(defaultTimeEncoder.append(nil, at.Add(time.Millisecond)))
+
+	if first != "2024-12-31T23:59:59.999Z" || second != "2025-01-01T00:00:00.000Z" {
+		t.Errorf("Unexpected timestamps around a second change: %s, %s", first, second)
+	}
+}
+
+// TestTimestampsIncreaseWithinSecond tests that entries logged in the same
+// second get increasing fractions instead of a frozen cached value
+func TestTimestampsIncreaseWithinSecond(t *testing.T) {
+	setTestPrecision(t, MillisecondPrecision)
+
+	var buf bytes.Buffer
+	clock := emittest.NewClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	testLogger := newTestLogger(&buf, withClock(clock))
+
+	for i := 0; i < 5; i++ {
+		testLogger.log(INFO, "tick", nil)
+		clock.Add(time.Millisecond)
+		testLogger.logStructuredFields(INFO, "tock", ZInt("i", i))
+		clock.Add(time.Millisecond)
+	}
+
+	pattern := regexp.MustCompile(`"timestamp":"(\d{4}-\d\d-\d\dT\d\d:\d\d:\d\d)\.(\d{3})Z"`)
+	matches := pattern.FindAllStringSubmatch(buf.String(), -1)
+	if len(matches) != 10 {
+		t.Fatalf("Expected 10 millisecond timestamps, got %d in %s", len(matches), buf.String())
+	}
+
+	for i := 2; i < len(matches); i += 2 {
+		if matches[i][1] != matches[0][1] {
+			t.Fatalf("Entries crossed a second boundary: %s", buf.String())
+		}
+		if matches[i][2] <= matches[i-2][2] {
+			t.Errorf("Expected increasing timestamps, got .%s after .%s", matches[i][2], matches[i-2][2])
+		}
+		if matches[i][2] < matches[i-1][2] {
+			t.Errorf("Structured entry .%s is later than the next entry .%s", matches[i-1][2], matches[i][2])
+		}
+	}
+}
+
+// TestTimestampPrecisionAllPaths tests that every JSON path honors the precision
+func TestTimestampPrecisionAllPaths(t *testing.T) {
+	setTestPrecision(t, NanosecondPrecision)
+
+	var buf bytes.Buffer
+	testLogger := newTestLogger(&buf, withComponent("api", ""))
+	testLogger.log(INFO, "simple", nil)
+	testLogger.log(INFO, "map", map[string]any{"k": "v"})
+	testLogger.logStructuredFields(INFO, "structured", ZString("k", "v"))
+	testLogger.profile = otelLayout
+	testLogger.log(INFO, "profile", nil)
+
+	nanos := regexp.MustCompile(`T\d\d:\d\d:\d\d\.\d{9}Z"`)
+	if got := len(nanos.FindAllString(buf.String(), -1)); got != 4 {
+		t.Errorf("Expected 4 nanosecond timestamps, got %d in %s", got, buf.String())
+	}
+}
+
+// TestTimeEncoderFormats tests each format, zone and layout
+func TestTimeEncoderFormats(t *testing.T) {
+	setTestPrecision(t, MillisecondPrecision)
+	at := time.Date(2024, 5, 1, 12, 30, 45, 123456789, time.UTC)
+
+	tests := []struct {
+		encoder TimeEncoder
+		want    string
+	}{
+		{TimeEncoder{}, "2024-05-01T12:30:45.123Z"},
+		{TimeEncoder{Location: time.FixedZone("IST", 5*60*60+30*60)}, "2024-05-01T18:00:45.123+05:30"},
+		{TimeEncoder{Location: time.FixedZone("EST", -5*60*60)}, "2024-05-01T07:30:45.123-05:00"},
+		{TimeEncoder{Format: TIME_EPOCH_SECONDS}, "1714566645.123"},
+		{TimeEncoder{Format: TIME_EPOCH_MILLIS}, "1714566645123"},
+		{TimeEncoder{Format: TIME_EPOCH_NANOS}, "1714566645123456789"},
+		{TimeEncoder{Format: TIME_LAYOUT, Layout: "2006/01/02 15:04:05 MST"}, "2024/05/01 12:30:45 UTC"},
+		{TimeEncoder{Format: TIME_LAYOUT}, "2024-05-01T12:30:45.123Z"},
+	}
+
+	for _, tt := range tests {
+		encoder := newTimeEncoder(tt.encoder)
+		if got := string(encoder.append(nil, at)); got != tt.want {
+			t.Errorf("%+v: expected %s, got %s", tt.encoder, tt.want, got)
+		}
+		if got := len(encoder.append(nil, at)); got > encoder.maxLen {
+			t.Errorf("%+v: %d bytes exceed the maximum of %d", tt.encoder, got, encoder.maxLen)
+		}
+	}
+}
+
+// TestTimeEncoderAllPaths tests that every formatter uses the time encoder,
+// writing epoch timestamps as JSON numbers
+func TestTimeEncoderAllPaths(t *testing.T) {
+	var buf bytes.Buffer
+	testLogger := newTestLogger(&buf, withComponent("api", ""), withTimeEncoder(TimeEncoder{Format: TIME_EPOCH_MILLIS}))
+
+	testLogger.log(INFO, "simple", nil)
+	testLogger.log(INFO, "map", map[string]any{"k": "v"})
+	testLogger.logStructuredFields(WARN, "structured", ZString("k", "v"))
+	testLogger.logStructuredFieldsDynamic(ERROR, "dynamic", ZString("k", "v"))
+	testLogger.profile = otelLayout
+	testLogger.log(INFO, "profile", nil)
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	if len(lines) != 5 {
+		t.Fatalf("Expected 5 entries, got %q", buf.String())
+	}
+	for _, line := range lines {
+		var entry map[string]any
+		if err := json.Unmarshal(line, &entry); err != nil {
+			t.Fatalf("Invalid JSON %s: %v", line, err)
+		}
+		timestamp, ok := entry["timestamp"]
+		if !ok {
+			timestamp = entry["Timestamp"]
+		}
+		if _, ok := timestamp.(float64); !ok {
+			t.Errorf("Expected a numeric timestamp in %s", line)
+		}
+	}
+
+	buf.Reset()
+	testLogger.profile = nil
+	testLogger.format = PLAIN_FORMAT
+	testLogger.timeEncoder = newTimeEncoder(TimeEncoder{Format: TIME_LAYOUT, Layout: "Jan _2 15:04:05"})
+	testLogger.log(INFO, "simple", nil)
+	testLogger.log(INFO, "map", map[string]any{"k": "v"})
+	testLogger.format = LOGFMT_FORMAT
+	testLogger.log(INFO, "logfmt", nil)
+
+	pattern := regexp.MustCompile(`^(ts="[A-Z][a-z]{2} [ \d]\d \d\d:\d\d:\d\d"|[A-Z][a-z]{2} [ \d]\d \d\d:\d\d:\d\d \| )`)
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if !pattern.MatchString(line) {
+			t.Errorf("Expected a layout timestamp at the start of %q", line)
+		}
+	}
+}
+
+// TestTimeEncoderNoAllocation tests that numeric and zoned timestamps keep
+// the structured path allocation-free
+func TestTimeEncoderNoAllocation(t *testing.T) {
+	for _, encoder := range []TimeEncoder{
+		{Format: TIME_EPOCH_NANOS},
+		{Location: time.FixedZone("CET", 60*60)},
+	} {
+		testLogger := newTestLogger(io.Discard, withLevel(INFO), withTimeEncoder(encoder))
+		fields := []ZField{ZString("method", "GET"), ZInt("status", 200)}
+		allocs := testing.AllocsPerRun(100, func() {
+			testLogger.logStructuredFields(INFO, "request", fields...)
+		})
+		if allocs != 0 {
+			t.Errorf("%+v: expected 0 allocations, got %v", encoder, allocs)
+		}
+	}
+}
+
+// TestParseTimeEncoder tests the EMIT_TIME_FORMAT values
+func TestParseTimeEncoder(t *testing.T) {
+	if got, err := ParseTimeEncoder("epoch_ms"); err != nil || got.Format != TIME_EPOCH_MILLIS {
+		t.Errorf("Expected epoch milliseconds, got %+v, %v", got, err)
+	}
+	if got, err := ParseTimeEncoder("local"); err != nil || got.Location != time.Local {
+		t.Errorf("Expected the local zone, got %+v, %v", got, err)
+	}
+	if got, err := ParseTimeEncoder("layout:2006-01-02 15:04"); err != nil || got.Format != TIME_LAYOUT || got.Layout != "2006-01-02 15:04" {
+		t.Errorf("Expected a layout, got %+v, %v", got, err)
+	}
+	for _, format := range []string{"rfc3339x", "15 minutes", "2006-01-02", "layout:"} {
+		if _, err := ParseTimeEncoder(format); err == nil {
+			t.Errorf("Expected an error for %q", format)
+		}
+	}
+}
@@ -18,6 +18,9 @@ type OutputFormat int
 const (
 	JSON_FORMAT OutputFormat = iota
 	PLAIN_FORMAT
+	LOGFMT_FORMAT
+	CBOR_FORMAT    // Binary CBOR (RFC 8949), see CBORToJSON
+	CONSOLE_FORMAT // Developer console, see ConsoleConfig
 )
 
 // SensitiveDataMode represents how to handle sensitive data
@@ -63,4 +66,18 @@ type Logger struct {
 	piiFields       []string
 	maskString      string
 	piiMaskString   string
+	sampler         *sampler
+	limiter         *rateLimiter
+	hooks           *hookChain
+	metrics         Metrics
+	writeErrors     *writeErrorState
+	profile         *entryLayout
+	console         *entryLayout
+	template        *entryLayout
+	timeEncoder     *timeEncoder
+	clock           Clock
+	callerSkip      int
+	callerFormat    CallerFormat
+	stacks          *stackOptions
+	enrichment      *enrichment
 }
@@ -0,0 +1,144 @@
+package emit
+
+import (
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// ErrorHandler is called when the writer fails to write an entry
+type ErrorHandler func(err error)
+
+// WriteErrorConfig configures how write failures are handled.
+//
+// Without it, failed writes are only counted by Metrics. With a Fallback,
+// entries go to the fallback after FallbackAfter consecutive failures; the
+// primary writer is retried every RetryInterval and used again as soon as a
+// write succeeds.
+type WriteErrorConfig struct {
+	// Handler is called with write errors, at most once per HandlerInterval.
+	// The error reports how many errors were suppressed since the last call.
+	// Logging through the failing logger from Handler will not recurse but
+	// is usually lost.
+	Handler ErrorHandler
+
+	// HandlerInterval rate-limits Handler (default: 1s)
+	HandlerInterval time.Duration
+
+	// Fallback receives entries while the primary writer is failing, e.g. os.Stderr (optional)
+	Fallback io.Writer
+
+	// FallbackAfter is the number of consecutive failures before switching (default: 3)
+	FallbackAfter int
+
+	// RetryInterval is how often the primary writer is retried while on the fallback (default: 1s)
+	RetryInterval time.Duration
+}
+
+// writeErrorState tracks failures of the primary writer
+type writeErrorState struct {
+	handler  ErrorHandler
+	interval int64
+	fallback io.Writer
+	after    int64
+	retry    int64
+
+	lastReported atomic.Int64
+	suppressed   atomic.Uint64
+
+	failures   atomic.Int64
+	onFallback atomic.Bool
+	nextProbe  atomic.Int64
+}
+
+// newWriteErrorState applies the configuration defaults
+func newWriteErrorState(config WriteErrorConfig) *writeErrorState {
+	if config.HandlerInterval <= 0 {
+		config.HandlerInterval = time.Second
+	}
+	if config.FallbackAfter <= 0 {
+		config.FallbackAfter = 3
+	}
+	if config.RetryInterval <= 0 {
+		config.RetryInterval = time.Second
+	}
+
+	return &writeErrorState{
+		handler:  config.Handler,
+		interval: int64(config.HandlerInterval),
+		fallback: config.Fallback,
+		after:    int64(config.FallbackAfter),
+		retry:    int64(config.RetryInterval),
+	}
+}
+
+// write writes p to the primary writer, switching to and from the fallback
+func (s *writeErrorState) write(clock Clock, primary io.Writer, p []byte) (int, error) {
+	if s.onFallback.Load() {
+		// One writer per retry interval probes the primary, the rest use the fallback
+		now := clock.Now().UnixNano()
+		next := s.nextProbe.Load()
+		if now < next || !s.nextProbe.CompareAndSwap(next, now+s.retry) {
+			return s.fallback.Write(p)
+		}
+	}
+
+	n, err := primary.Write(p)
+	if err == nil {
+		s.failures.Store(0)
+		s.onFallback.Store(false)
+		return n, nil
+	}
+
+	s.report(clock, err)
+
+	if s.fallback == nil {
+		return n, err
+	}
+	if s.onFallback.Load() || s.failures.Add(1) >= s.after {
+		if !s.onFallback.Swap(true) {
+			s.nextProbe.Store(clock.Now().UnixNano() + s.retry)
+		}
+		return s.fallback.Write(p)
+	}
+	return n, err
+}
+
+// report calls the handler unless it was called within the interval
+func (s *writeErrorState) report(clock Clock, err error) {
+	if s.handler == nil {
+		return
+	}
+
+	now := clock.Now().UnixNano()
+	last := s.lastReported.Load()
+	if (last != 0 && now-last < s.interval) || !s.lastReported.CompareAndSwap(last, now) {
+		s.suppressed.Add(1)
+		return
+	}
+
+	if suppressed := s.suppressed.Swap(0); suppressed > 0 {
+		err = fmt.Errorf("%w (%d more write errors suppressed)", err, suppressed)
+	}
+	s.handler(err)
+}
+
+// SetWriteErrorHandling configures write failure handling on the default logger
+func SetWriteErrorHandling(config WriteErrorConfig) {
+	if defaultLogger != nil {
+		defaultLogger.writeErrors = newWriteErrorState(config)
+	}
+}
+
+// SetErrorHandler calls handler (rate-limited) when the default logger fails to write
+func SetErrorHandler(handler ErrorHandler) {
+	SetWriteErrorHandling(WriteErrorConfig{Handler: handler})
+}
+
+// DisableWriteErrorHandling restores the default of counting failed writes only
+func DisableWriteErrorHandling() {
+	if defaultLogger != nil {
+		defaultLogger.writeErrors = nil
+	}
+}
@@ -0,0 +1,81 @@
+package emit
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudresty/emit/emittest"
+)
+
+// TestWriteErrorFallback tests switching to the fallback writer and back
+func TestWriteErrorFallback(t *testing.T) {
+	primary := &flakyWriter{}
+	var fallback bytes.Buffer
+	var reported []error
+	clock := emittest.NewClock(time.Now())
+
+	testLogger := newTestLogger(primary, withClock(clock), withWriteErrors(WriteErrorConfig{
+		Handler:         func(err error) { reported = append(reported, err) },
+		HandlerInterval: time.Hour,
+		Fallback:        &fallback,
+		FallbackAfter:   2,
+		RetryInterval:   20 * time.Millisecond,
+	}))
+
+	primary.down.Store(true)
+	testLogger.log(INFO, "lost", nil)
+	testLogger.log(INFO, "first on fallback", nil)
+	testLogger.logStructuredFields(INFO, "second on fallback", ZInt("n", 2))
+
+	if strings.Contains(fallback.String(), "lost") {
+		t.Error("Entries before the threshold should not reach the fallback")
+	}
+	if !strings.Contains(fallback.String(), "first on fallback") || !strings.Contains(fallback.String(), "second on fallback") {
+		t.Errorf("Expected entries on the fallback after 2 failures, got:\n%s", fallback.String())
+	}
+
+	// Only the first error is reported within the interval
+	if len(reported) != 1 || !strings.Contains(reported[0].Error(), "sink unavailable") {
+		t.Errorf("Expected one rate-limited error report, got %v", reported)
+	}
+
+	// Recovery: the next probe after the retry interval succeeds
+	primary.down.Store(false)
+	clock.Add(30 * time.Millisecond)
+	testLogger.log(INFO, "recovered", nil)
+	testLogger.log(INFO, "back on primary", nil)
+
+	if !strings.Contains(strings.Join(primary.received(), ""), "recovered") || !strings.Contains(strings.Join(primary.received(), ""), "back on primary") {
+		t.Errorf("Expected entries back on the primary writer, got:\n%s", strings.Join(primary.received(), ""))
+	}
+	if strings.Contains(fallback.String(), "recovered") {
+		t.Error("Recovered entries should not reach the fallback")
+	}
+}
+
+// TestErrorHandlerSuppressedCount tests that suppressed errors are reported with the next call
+func TestErrorHandlerSuppressedCount(t *testing.T) {
+	var reported []error
+	state := newWriteErrorState(WriteErrorConfig{
+		Handler:         func(err error) { reported = append(reported, err) },
+		HandlerInterval: 20 * time.Millisecond,
+	})
+
+	clock := emittest.NewClock(time.Now())
+	cause := errors.New("disk full")
+	for i := 0; i < 5; i++ {
+		state.report(clock, cause)
+	}
+	clock.Add(30 * time.Millisecond)
+	state.report(clock, cause)
+
+	if len(reported) != 2 {
+		t.Fatalf("Expected 2 reports, got %d", len(reported))
+	}
+	if !errors.Is(reported[1], cause) || !strings.Contains(reported[1].Error(), "4 more write errors suppressed") {
+		t.Errorf("Unexpected second report: %v", reported[1])
+	}
+}
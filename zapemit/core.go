@@ -0,0 +1,129 @@
+// Package zapemit writes zap loggers through emit, so existing *zap.Logger
+// call sites get emit's output formats and masking without being rewritten.
+//
+//	logger := zap.New(zapemit.NewCore(zapcore.DebugLevel))
+//	logger.Info("login", zap.String("user", "alice"), zap.String("password", pw))
+//	// {"level":"info","message":"login","fields":{"password":"***MASKED***","user":"alice"},...}
+//
+// Zap's encoders are not used: emit formats every entry, and with
+// emit.SetShowCaller reports the zap call site whether or not zap.AddCaller
+// is set.
+package zapemit
+
+import (
+	"maps"
+	"runtime"
+	"strings"
+
+	"github.com/cloudresty/emit"
+	"go.uber.org/zap/zapcore"
+)
+
+// Core is a zapcore.Core writing entries to emit's default logger. Zap's
+// level enabler decides which entries reach emit; emit's own level, masking,
+// sampling and sinks then apply as for any other entry.
+type Core struct {
+	zapcore.LevelEnabler
+
+	context map[string]any // Fields added with With
+}
+
+// NewCore returns a core writing the entries enabled by enabler through emit
+func NewCore(enabler zapcore.LevelEnabler) *Core {
+	return &Core{LevelEnabler: enabler}
+}
+
+// With returns a core adding fields to every entry
+func (c *Core) With(fields []zapcore.Field) zapcore.Core {
+	enc := zapcore.NewMapObjectEncoder()
+	maps.Copy(enc.Fields, c.context)
+	for _, field := range fields {
+		field.AddTo(enc)
+	}
+	return &Core{LevelEnabler: c.LevelEnabler, context: enc.Fields}
+}
+
+// Check adds the core to entries it writes
+func (c *Core) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+// Write logs an entry through emit. The logger name, zap's caller and
+// stack trace (when zap adds them) become the logger, caller and
+// stacktrace fields.
+func (c *Core) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	enc := zapcore.NewMapObjectEncoder()
+	maps.Copy(enc.Fields, c.context)
+	for _, field := range fields {
+		field.AddTo(enc)
+	}
+
+	if entry.LoggerName != "" {
+		enc.Fields["logger"] = entry.LoggerName
+	}
+	if entry.Caller.Defined {
+		enc.Fields["caller"] = entry.Caller.TrimmedPath()
+	}
+	if entry.Stack != "" {
+		enc.Fields["stacktrace"] = entry.Stack
+	}
+
+	emit.With().AddCallerSkip(callerSkip(entry)).LogFields(emitLevel(entry.Level), entry.Message, emit.Fields(enc.Fields))
+	return nil
+}
+
+// Sync does nothing, since emit writes entries as they are logged
+func (c *Core) Sync() error {
+	return nil
+}
+
+// emitLevel maps a zap level to an emit level. DPanic, Panic and Fatal
+// entries are written as errors; zap panics or exits after writing them.
+func emitLevel(level zapcore.Level) emit.LogLevel {
+	switch {
+	case level <= zapcore.DebugLevel:
+		return emit.DEBUG
+	case level == zapcore.InfoLevel:
+		return emit.INFO
+	case level == zapcore.WarnLevel:
+		return emit.WARN
+	default:
+		return emit.ERROR
+	}
+}
+
+// callerSkip counts the frames between Write and the code that logged: up
+// to zap's own caller when zap records one, otherwise past zap's frames
+func callerSkip(entry zapcore.Entry) int {
+	var pcs [64]uintptr
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs[:])])
+
+	skip := 0
+	for {
+		frame, more := frames.Next()
+		if entry.Caller.Defined {
+			if frame.File == entry.Caller.File && frame.Line == entry.Caller.Line {
+				return skip
+			}
+		} else if !isZapFrame(frame) {
+			return skip
+		}
+		if !more {
+			return 0
+		}
+		skip++
+	}
+}
+
+// isZapFrame reports whether a frame belongs to zap or this adapter (but
+// not its tests)
+func isZapFrame(frame runtime.Frame) bool {
+	if strings.HasSuffix(frame.File, "_test.go") {
+		return false
+	}
+	return strings.HasPrefix(frame.Function, "go.uber.org/zap") ||
+		strings.HasPrefix(frame.Function, "github.com/cloudresty/emit/zapemit.")
+}
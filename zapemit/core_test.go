@@ -0,0 +1,115 @@
+package zapemit
+
+import (
+	"errors"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/cloudresty/emit"
+	"github.com/cloudresty/emit/emittest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// captureEmit records the default logger's JSON entries for one test
+func captureEmit(t *testing.T) *emittest.Recorder {
+	emit.SetJSONFormat()
+	emit.SetLevel("debug")
+	return emittest.Capture(t, emit.GetOutput, emit.SetOutput)
+}
+
+// TestCoreFieldsAndMasking tests that zap fields reach emit and are masked
+func TestCoreFieldsAndMasking(t *testing.T) {
+	rec := captureEmit(t)
+	logger := zap.New(NewCore(zapcore.DebugLevel)).Named("auth").With(zap.String("request_id", "r-1"))
+
+	logger.Warn("login failed",
+		zap.String("user", "alice"),
+		zap.String("password", "hunter2"),
+		zap.Int("attempt", 3),
+		zap.Error(errors.New("bad password")))
+
+	entries := rec.Entries(t)
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 entry, got %q", rec.String())
+	}
+	got := entries[0]
+	if got.Level != "warn" || got.Message != "login failed" {
+		t.Errorf("Expected a warn entry, got %q", rec.String())
+	}
+	want := map[string]any{
+		"request_id": "r-1",
+		"user":       "alice",
+		"password":   "***MASKED***",
+		"attempt":    float64(3),
+		"error":      "bad password",
+		"logger":     "auth",
+	}
+	for key, value := range want {
+		if got.Fields[key] != value {
+			t.Errorf("Expected %s=%v, got %v in %q", key, value, got.Fields[key], rec.String())
+		}
+	}
+}
+
+// TestCoreLevels tests the level mapping and zap's level enabler
+func TestCoreLevels(t *testing.T) {
+	rec := captureEmit(t)
+	logger := zap.New(NewCore(zapcore.InfoLevel))
+
+	logger.Debug("filtered by zap")
+	logger.Info("info")
+	logger.Warn("warn")
+	logger.Error("error")
+	logger.DPanic("dpanic")
+
+	entries := rec.Entries(t)
+	levels := []string{"info", "warn", "error", "error"}
+	if len(entries) != len(levels) {
+		t.Fatalf("Expected %d entries, got %q", len(levels), rec.String())
+	}
+	for i, level := range levels {
+		if entries[i].Level != level {
+			t.Errorf("Entry %d: expected level %s, got %s", i, level, entries[i].Level)
+		}
+	}
+}
+
+// TestCoreWithIsolation tests that With does not leak fields into its parent
+func TestCoreWithIsolation(t *testing.T) {
+	rec := captureEmit(t)
+	parent := zap.New(NewCore(zapcore.DebugLevel))
+	parent.With(zap.String("child", "yes")).Info("child")
+	parent.Info("parent")
+
+	entries := rec.Entries(t)
+	if len(entries) != 2 || entries[0].Fields["child"] != "yes" || entries[1].Fields != nil {
+		t.Errorf("Expected the field only on the child's entry, got %q", rec.String())
+	}
+}
+
+// TestCoreCaller tests that emit reports the zap call site, with and
+// without zap's own caller annotation and through the sugared logger
+func TestCoreCaller(t *testing.T) {
+	rec := captureEmit(t)
+	emit.SetShowCaller(true)
+	t.Cleanup(func() { emit.SetShowCaller(false) })
+
+	plain := zap.New(NewCore(zapcore.DebugLevel))
+	annotated := zap.New(NewCore(zapcore.DebugLevel), zap.AddCaller())
+	_, _, line, _ := runtime.Caller(0)
+	plain.Info("plain")
+	annotated.Info("annotated")
+	plain.Sugar().Infow("sugared", "k", "v")
+
+	entries := rec.Entries(t)
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 entries, got %q", rec.String())
+	}
+	for i, e := range entries {
+		if filepath.Base(e.File) != "core_test.go" || e.Line != line+1+i {
+			t.Errorf("%s: expected core_test.go:%d, got %s:%d", e.Message, line+1+i, e.File, e.Line)
+		}
+	}
+}
@@ -0,0 +1,12 @@
+module github.com/cloudresty/emit/zapemit
+
+go 1.24
+
+require (
+	github.com/cloudresty/emit v1.2.0
+	go.uber.org/zap v1.27.0
+)
+
+require go.uber.org/multierr v1.10.0 // indirect
+
+replace github.com/cloudresty/emit => ../
@@ -13,6 +13,10 @@ type ZField interface {
 	IsPII() bool
 }
 
+// zfieldMask replaces sensitive and PII ZField values, both in the output
+// and in the values hooks see
+const zfieldMask = "***MASKED***"
+
 // StringZField represents a string field with zero allocations
 type StringZField struct {
 	Key   string
@@ -20,10 +24,8 @@ type StringZField struct {
 }
 
 func (f StringZField) WriteToEncoder(enc *ZeroAllocEncoder) {
-	if f.IsSensitive() {
-		enc.writeStringField(f.Key, "***MASKED***")
-	} else if f.IsPII() {
-		enc.writeStringField(f.Key, "***PII***")
+	if f.IsSensitive() || f.IsPII() {
+		enc.writeStringField(f.Key, zfieldMask)
 	} else {
 		enc.writeStringField(f.Key, f.Value)
 	}